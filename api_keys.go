@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"internal/auth"
+	"internal/database"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const maxAPIKeyNameLength = 100
+
+// APIKey is an issued key. Key is only set in the response that creates it.
+type APIKey struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	CreatedBy uuid.UUID `json:"created_by"`
+	Key       string    `json:"key,omitempty"`
+}
+
+// handlerCreateAPIKey issues a key for an integration to identify itself
+// with, so rate limits scoped to API keys count it on its own.
+func (cfg *apiConfig) handlerCreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Name string `json:"name"`
+	}
+	adminID, err := cfg.authenticatedStaff(r, roleAdmin)
+	if errors.Is(err, errNotStaff) {
+		respondWithError(w, http.StatusForbidden, "Admins only", nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized access", nil)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", nil)
+		return
+	}
+	name := strings.TrimSpace(params.Name)
+	if name == "" || len(name) > maxAPIKeyNameLength {
+		respondWithError(w, http.StatusBadRequest, "Name must be between 1 and 100 characters", nil)
+		return
+	}
+	key, err := auth.MakeAPIKey()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create API key", nil)
+		return
+	}
+
+	dbKey, err := cfg.db.CreateAPIKey(r.Context(), database.CreateAPIKeyParams{
+		Name:      name,
+		KeyHash:   auth.HashAPIKey(key),
+		CreatedBy: adminID,
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not create API key in DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create API key", nil)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	respondWithJSON(w, http.StatusCreated, APIKey{
+		ID:        dbKey.ID,
+		Name:      dbKey.Name,
+		CreatedAt: dbKey.CreatedAt,
+		CreatedBy: dbKey.CreatedBy,
+		Key:       key,
+	})
+}
+
+func (cfg *apiConfig) handlerRevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	_, err := cfg.authenticatedStaff(r, roleAdmin)
+	if errors.Is(err, errNotStaff) {
+		respondWithError(w, http.StatusForbidden, "Admins only", nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized access", nil)
+		return
+	}
+	keyID, err := uuid.Parse(r.PathValue("keyID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid API key ID", nil)
+		return
+	}
+
+	revoked, err := cfg.db.RevokeAPIKey(r.Context(), keyID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not revoke API key in DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't revoke API key", nil)
+		return
+	}
+	if revoked == 0 {
+		respondWithError(w, http.StatusNotFound, "Couldn't find API key", nil)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"internal/auth"
+	"net/http"
+)
+
+// Staff roles, granted in the database. Admins can do anything a moderator
+// can.
+const (
+	roleModerator = "moderator"
+	roleAdmin     = "admin"
+)
+
+var errNotStaff = errors.New("user doesn't have the required role")
+
+func (cfg *apiConfig) authenticatedUserID(r *http.Request) (uuid.UUID, error) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return auth.ValidateJWT(token, cfg.secretToken)
+}
+
+// viewerID returns the authenticated user for endpoints that also serve
+// anonymous requests, or uuid.Nil when there is no valid token.
+func (cfg *apiConfig) viewerID(r *http.Request) uuid.UUID {
+	userID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		return uuid.Nil
+	}
+	return userID
+}
+
+// authenticatedStaff returns the authenticated user if they hold role, or
+// errNotStaff if they're signed in without it.
+func (cfg *apiConfig) authenticatedStaff(r *http.Request, role string) (uuid.UUID, error) {
+	userID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	user, err := cfg.db.GetUserByID(r.Context(), userID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if user.Role != role && user.Role != roleAdmin {
+		return uuid.Nil, errNotStaff
+	}
+	return userID, nil
+}
+
+// staffTarget authenticates a member of staff holding role and resolves the
+// {userID} they act on. It writes the error response itself and reports
+// whether to continue.
+func (cfg *apiConfig) staffTarget(w http.ResponseWriter, r *http.Request, role string) (staffID, targetID uuid.UUID, ok bool) {
+	staffID, err := cfg.authenticatedStaff(r, role)
+	if errors.Is(err, errNotStaff) && role == roleAdmin {
+		respondWithError(w, http.StatusForbidden, "Admins only", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	if errors.Is(err, errNotStaff) {
+		respondWithError(w, http.StatusForbidden, "Moderators only", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized access", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	targetID, err = uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	if _, err := cfg.db.GetUserByID(r.Context(), targetID); err != nil {
+		respondWithError(w, http.StatusNotFound, "Couldn't find user", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	return staffID, targetID, true
+}
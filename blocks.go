@@ -0,0 +1,125 @@
+package main
+
+import (
+	"github.com/google/uuid"
+	"internal/database"
+	"log/slog"
+	"net/http"
+)
+
+// targetUser authenticates the request and resolves the {userID} it acts on.
+// It writes the error response itself and reports whether to continue.
+func (cfg *apiConfig) targetUser(w http.ResponseWriter, r *http.Request) (userID, targetID uuid.UUID, ok bool) {
+	userID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized access", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	targetID, err = uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	if targetID == userID {
+		respondWithError(w, http.StatusBadRequest, "You can't do that to yourself", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	if _, err := cfg.db.GetUserByID(r.Context(), targetID); err != nil {
+		respondWithError(w, http.StatusNotFound, "Couldn't find user", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	return userID, targetID, true
+}
+
+func (cfg *apiConfig) handlerBlockUser(w http.ResponseWriter, r *http.Request) {
+	userID, targetID, ok := cfg.targetUser(w, r)
+	if !ok {
+		return
+	}
+
+	// A block severs the follow relationship in both directions, and the
+	// two go together so no follow outlives the block.
+	err := cfg.withTx(r.Context(), func(q *database.Queries) error {
+		err := q.CreateBlock(r.Context(), database.CreateBlockParams{
+			BlockerID: userID,
+			BlockedID: targetID,
+		})
+		if err != nil {
+			return err
+		}
+		for _, pair := range [][2]uuid.UUID{{userID, targetID}, {targetID, userID}} {
+			_, err := q.DeleteFollow(r.Context(), database.DeleteFollowParams{
+				FollowerID: pair[0],
+				FolloweeID: pair[1],
+			})
+			if err != nil {
+				return err
+			}
+			err = q.PruneTimeline(r.Context(), database.PruneTimelineParams{
+				UserID:     pair[0],
+				FolloweeID: pair[1],
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not create block in DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't block user", nil)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerUnblockUser(w http.ResponseWriter, r *http.Request) {
+	userID, targetID, ok := cfg.targetUser(w, r)
+	if !ok {
+		return
+	}
+	err := cfg.db.DeleteBlock(r.Context(), database.DeleteBlockParams{
+		BlockerID: userID,
+		BlockedID: targetID,
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not delete block in DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't unblock user", nil)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerMuteUser(w http.ResponseWriter, r *http.Request) {
+	userID, targetID, ok := cfg.targetUser(w, r)
+	if !ok {
+		return
+	}
+	err := cfg.db.CreateMute(r.Context(), database.CreateMuteParams{
+		MuterID: userID,
+		MutedID: targetID,
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not create mute in DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't mute user", nil)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerUnmuteUser(w http.ResponseWriter, r *http.Request) {
+	userID, targetID, ok := cfg.targetUser(w, r)
+	if !ok {
+		return
+	}
+	err := cfg.db.DeleteMute(r.Context(), database.DeleteMuteParams{
+		MuterID: userID,
+		MutedID: targetID,
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not delete mute in DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't unmute user", nil)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
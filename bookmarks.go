@@ -0,0 +1,193 @@
+package main
+
+import (
+	"github.com/google/uuid"
+	"internal/database"
+	"log/slog"
+	"net/http"
+	"slices"
+)
+
+// maxPinnedChirps is how many chirps a user can pin, as the number of slots
+// CreatePin offers.
+const maxPinnedChirps = 3
+
+func (cfg *apiConfig) handlerBookmarkChirp(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized access", nil)
+		return
+	}
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", nil)
+		return
+	}
+	_, err = cfg.db.GetChirpByID(r.Context(), database.GetChirpByIDParams{
+		ID:       chirpID,
+		ViewerID: userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Couldn't retrieve chirp", nil)
+		return
+	}
+
+	err = cfg.db.CreateBookmark(r.Context(), database.CreateBookmarkParams{
+		UserID:  userID,
+		ChirpID: chirpID,
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not create bookmark in DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't bookmark chirp", nil)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerRemoveBookmark(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized access", nil)
+		return
+	}
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", nil)
+		return
+	}
+
+	err = cfg.db.DeleteBookmark(r.Context(), database.DeleteBookmarkParams{
+		UserID:  userID,
+		ChirpID: chirpID,
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not delete bookmark from DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't remove bookmark", nil)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerGetBookmarks lists the user's bookmarks, most recently bookmarked
+// first. Bookmarks are private, so there's only ever the user's own list.
+func (cfg *apiConfig) handlerGetBookmarks(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized access", nil)
+		return
+	}
+	p, err := parsePage(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	rows, err := cfg.db.GetBookmarks(r.Context(), database.GetBookmarksParams{
+		UserID:          userID,
+		CursorCreatedAt: p.cursorCreatedAt,
+		CursorID:        p.cursorID,
+		PageSize:        p.size,
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not retrieve bookmarks from DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve bookmarks", nil)
+		return
+	}
+	dbChirps := make([]database.Chirp, 0, len(rows))
+	for _, row := range rows {
+		dbChirps = append(dbChirps, row.Chirp)
+	}
+	chirps, err := cfg.loadChirps(r.Context(), userID, dbChirps)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not load chirps from DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve bookmarks", nil)
+		return
+	}
+
+	resp := ChirpPage{Chirps: chirps}
+	if len(rows) > 0 {
+		last := rows[len(rows)-1]
+		resp.NextCursor = p.nextCursor(len(rows), last.BookmarkedAt, last.Chirp.ID)
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+func (cfg *apiConfig) handlerPinChirp(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized access", nil)
+		return
+	}
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", nil)
+		return
+	}
+	chirp, err := cfg.db.GetChirpByID(r.Context(), database.GetChirpByIDParams{
+		ID:       chirpID,
+		ViewerID: userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Couldn't retrieve chirp", nil)
+		return
+	}
+	if chirp.UserID != userID {
+		respondWithError(w, http.StatusForbidden, "You can only pin your own chirps", nil)
+		return
+	}
+
+	pinned, err := cfg.db.CreatePin(r.Context(), database.CreatePinParams{
+		UserID:  userID,
+		ChirpID: chirpID,
+		MaxPins: maxPinnedChirps,
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not create pin in DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't pin chirp", nil)
+		return
+	}
+	if pinned == 0 {
+		// Either it's already pinned, which is fine, or every slot is taken.
+		dbPinned, err := cfg.db.GetPinnedChirps(r.Context(), database.GetPinnedChirpsParams{
+			UserID:   userID,
+			ViewerID: userID,
+		})
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Could not retrieve pinned chirps from DB", "err", err)
+			respondWithError(w, http.StatusInternalServerError, "Couldn't pin chirp", nil)
+			return
+		}
+		alreadyPinned := slices.ContainsFunc(dbPinned, func(c database.Chirp) bool {
+			return c.ID == chirpID
+		})
+		if !alreadyPinned {
+			respondWithError(w, http.StatusConflict, "You can't pin any more chirps", nil)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerUnpinChirp(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized access", nil)
+		return
+	}
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", nil)
+		return
+	}
+
+	err = cfg.db.DeletePin(r.Context(), database.DeletePinParams{
+		UserID:  userID,
+		ChirpID: chirpID,
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not delete pin from DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't unpin chirp", nil)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
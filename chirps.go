@@ -1,92 +1,435 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"github.com/google/uuid"
-	"internal/auth"
+	"internal/content"
 	"internal/database"
-	"log"
+	"internal/moderation"
+	"internal/spam"
+	"internal/visibility"
+	"log/slog"
 	"net/http"
-	"strings"
 	"time"
 )
 
 type Chirp struct {
-	ID        uuid.UUID `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Body      string    `json:"body"`
-	UserID    uuid.UUID `json:"user_id"`
+	ID           uuid.UUID  `json:"id"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	Body         string     `json:"body"`
+	UserID       uuid.UUID  `json:"user_id"`
+	Visibility   string     `json:"visibility"`
+	InReplyToID  *uuid.UUID `json:"in_reply_to_id,omitempty"`
+	ReplyCount   int64      `json:"reply_count"`
+	LikeCount    int64      `json:"like_count"`
+	LikedByMe    *bool      `json:"liked_by_me,omitempty"`
+	RechirpCount int64      `json:"rechirp_count"`
+	QuoteCount   int64      `json:"quote_count"`
+	RechirpOf    *ChirpRef  `json:"rechirp_of,omitempty"`
+	QuoteOf      *ChirpRef  `json:"quote_of,omitempty"`
+	Media        []Media    `json:"media,omitempty"`
+	Poll         *Poll      `json:"poll,omitempty"`
+	Pinned       bool       `json:"pinned,omitempty"`
 }
 
-func (cfg *apiConfig) handlerCreateChirp(w http.ResponseWriter, r *http.Request) {
-	type parameters struct {
-		Body string `json:"body"`
+func newChirp(dbChirp database.Chirp) Chirp {
+	chirp := Chirp{
+		ID:         dbChirp.ID,
+		CreatedAt:  dbChirp.CreatedAt,
+		UpdatedAt:  dbChirp.UpdatedAt,
+		Body:       dbChirp.Body,
+		UserID:     dbChirp.UserID,
+		Visibility: dbChirp.Visibility,
+	}
+	if dbChirp.InReplyToID.Valid {
+		chirp.InReplyToID = &dbChirp.InReplyToID.UUID
 	}
-	token, err := auth.GetBearerToken(r.Header)
+	return chirp
+}
+
+// loadChirps converts DB chirps to their JSON form, fills in the aggregate
+// counts and embeds the originals of rechirps and quotes. Everything is
+// fetched in batches per listing. Fields that depend on who is looking are
+// only set when viewerID is not uuid.Nil.
+func (cfg *apiConfig) loadChirps(ctx context.Context, viewerID uuid.UUID, dbChirps []database.Chirp) ([]Chirp, error) {
+	chirps, err := cfg.loadChirpCounts(ctx, viewerID, dbChirps)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Couldn't get token", nil)
-		log.Printf("Couldn't get token: %v", err)
-		return
+		return nil, err
+	}
+	if err := cfg.embedOriginals(ctx, viewerID, chirps, dbChirps); err != nil {
+		return nil, err
+	}
+	return chirps, nil
+}
+
+func (cfg *apiConfig) loadChirpCounts(ctx context.Context, viewerID uuid.UUID, dbChirps []database.Chirp) ([]Chirp, error) {
+	chirps := make([]Chirp, 0, len(dbChirps))
+	ids := make([]uuid.UUID, 0, len(dbChirps))
+	for _, dbChirp := range dbChirps {
+		chirps = append(chirps, newChirp(dbChirp))
+		ids = append(ids, dbChirp.ID)
+	}
+	if len(ids) == 0 {
+		return chirps, nil
 	}
 
-	userID, err := auth.ValidateJWT(token, cfg.secretToken)
+	replyCounts, err := cfg.db.GetReplyCounts(ctx, ids)
 	if err != nil {
-		log.Printf("User not authorized: %v", err)
+		return nil, err
+	}
+	replies := make(map[uuid.UUID]int64, len(replyCounts))
+	for _, row := range replyCounts {
+		replies[row.InReplyToID.UUID] = row.Count
 	}
 
-	decoder := json.NewDecoder(r.Body)
-	params := parameters{}
-	err = decoder.Decode(&params)
+	likeCounts, err := cfg.db.GetLikeCounts(ctx, ids)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", nil)
-		log.Printf("Couldn't decode parameters: %v", err)
+		return nil, err
+	}
+	likes := make(map[uuid.UUID]int64, len(likeCounts))
+	for _, row := range likeCounts {
+		likes[row.ChirpID] = row.LikeCount
+	}
+
+	rechirpCounts, err := cfg.db.GetRechirpCounts(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	rechirps := make(map[uuid.UUID]database.GetRechirpCountsRow, len(rechirpCounts))
+	for _, row := range rechirpCounts {
+		rechirps[row.ChirpID] = row
+	}
+
+	var liked map[uuid.UUID]bool
+	if viewerID != uuid.Nil {
+		likedIDs, err := cfg.db.GetLikedChirpIDs(ctx, database.GetLikedChirpIDsParams{
+			UserID:   viewerID,
+			ChirpIds: ids,
+		})
+		if err != nil {
+			return nil, err
+		}
+		liked = make(map[uuid.UUID]bool, len(likedIDs))
+		for _, id := range likedIDs {
+			liked[id] = true
+		}
+	}
+
+	for i := range chirps {
+		chirps[i].ReplyCount = replies[chirps[i].ID]
+		chirps[i].LikeCount = likes[chirps[i].ID]
+		chirps[i].RechirpCount = rechirps[chirps[i].ID].RechirpCount
+		chirps[i].QuoteCount = rechirps[chirps[i].ID].QuoteCount
+		if liked != nil {
+			likedByMe := liked[chirps[i].ID]
+			chirps[i].LikedByMe = &likedByMe
+		}
+	}
+	if err := cfg.loadMedia(ctx, chirps, ids); err != nil {
+		return nil, err
+	}
+	polls, err := cfg.loadPolls(ctx, viewerID, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range chirps {
+		chirps[i].Poll = polls[chirps[i].ID]
+	}
+	return chirps, nil
+}
+
+// loadChirp is loadChirps for a single chirp.
+func (cfg *apiConfig) loadChirp(ctx context.Context, viewerID uuid.UUID, dbChirp database.Chirp) (Chirp, error) {
+	chirps, err := cfg.loadChirps(ctx, viewerID, []database.Chirp{dbChirp})
+	if err != nil {
+		return Chirp{}, err
+	}
+	return chirps[0], nil
+}
+
+// maxChirpMentions bounds the users a chirp can mention, and so notify.
+const maxChirpMentions = 10
+
+// chirpParams is the content of a new chirp, whether it's posted directly or
+// published from a draft.
+type chirpParams struct {
+	Body        string      `json:"body"`
+	InReplyToID *uuid.UUID  `json:"in_reply_to_id"`
+	QuoteOfID   *uuid.UUID  `json:"quote_of_id"`
+	Mentions    []uuid.UUID `json:"mentions"`
+	MediaIDs    []uuid.UUID `json:"media_ids"`
+	Poll        *pollParams `json:"poll"`
+	Visibility  string      `json:"visibility"`
+}
+
+// chirpError is a chirp that failed validation. Its message is meant for the
+// author.
+type chirpError struct {
+	status int
+	msg    string
+	// tooLong is set when the body is over the length limit.
+	tooLong *content.LengthError
+}
+
+func (e *chirpError) Error() string {
+	return e.msg
+}
+
+func (e *chirpError) respond(w http.ResponseWriter) {
+	if e.tooLong != nil {
+		respondWithLengthError(w, e.msg, e.tooLong)
 		return
 	}
+	respondWithError(w, e.status, e.msg, nil)
+}
 
+// createChirp validates params as a chirp by userID and inserts it through q,
+// which is normally a transaction. Invalid input is reported as a *chirpError.
+// Once the chirp is committed the caller runs publishChirp.
+func (cfg *apiConfig) createChirp(ctx context.Context, q *database.Queries, userID uuid.UUID, params chirpParams) (database.Chirp, error) {
 	const maxChirpLength = 140
-	if len(params.Body) > maxChirpLength {
-		respondWithError(w, http.StatusBadRequest, "Chirp is too long", nil)
+	author, err := q.GetUserByID(ctx, userID)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+	if restricted := accountRestriction(author); restricted != "" {
+		return database.Chirp{}, &chirpError{http.StatusForbidden, restricted, nil}
+	}
+
+	body, err := content.Check(params.Body, maxChirpLength)
+	var tooLong *content.LengthError
+	if errors.As(err, &tooLong) {
+		return database.Chirp{}, &chirpError{http.StatusBadRequest, "Chirp is too long", tooLong}
+	}
+	vis, err := validateVisibility(params.Visibility)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+
+	inReplyToID := uuid.NullUUID{}
+	if params.InReplyToID != nil {
+		parent, err := q.GetChirpByID(ctx, database.GetChirpByIDParams{
+			ID:       *params.InReplyToID,
+			ViewerID: userID,
+		})
+		if err != nil {
+			return database.Chirp{}, &chirpError{http.StatusBadRequest, "Chirp being replied to doesn't exist", nil}
+		}
+		inReplyToID = uuid.NullUUID{UUID: parent.ID, Valid: true}
+	}
+
+	quoteOfID := uuid.NullUUID{}
+	if params.QuoteOfID != nil {
+		quoted, err := q.GetChirpByID(ctx, database.GetChirpByIDParams{
+			ID:       *params.QuoteOfID,
+			ViewerID: userID,
+		})
+		if err != nil {
+			return database.Chirp{}, &chirpError{http.StatusBadRequest, "Chirp being quoted doesn't exist", nil}
+		}
+		quoteOfID = uuid.NullUUID{UUID: originalID(quoted), Valid: true}
+	}
+
+	if len(params.Mentions) > maxChirpMentions {
+		return database.Chirp{}, &chirpError{http.StatusBadRequest, "Too many mentions", nil}
+	}
+	if len(params.MediaIDs) > maxChirpMedia {
+		return database.Chirp{}, &chirpError{http.StatusBadRequest, "Too many attachments", nil}
+	}
+	if len(params.MediaIDs) > 0 {
+		attachable, err := q.CountAttachableMedia(ctx, database.CountAttachableMediaParams{
+			MediaIds: params.MediaIDs,
+			UserID:   userID,
+		})
+		if err != nil {
+			return database.Chirp{}, err
+		}
+		if attachable != int64(len(params.MediaIDs)) {
+			return database.Chirp{}, &chirpError{http.StatusBadRequest, "Invalid media attachment", nil}
+		}
+	}
+
+	if params.Poll != nil {
+		if err := validatePoll(params.Poll); err != nil {
+			return database.Chirp{}, err
+		}
+	}
+
+	screened := cfg.wordFilter.Load().Check(body)
+	if screened.Action == moderation.ActionReject {
+		return database.Chirp{}, &chirpError{http.StatusBadRequest, "Chirp contains a word that isn't allowed", nil}
+	}
+
+	score, verdict, err := cfg.scoreChirp(ctx, q, userID, screened.Text)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+
+	chirp, err := q.CreateChirp(ctx, database.CreateChirpParams{
+		Body:        screened.Text,
+		UserID:      userID,
+		InReplyToID: inReplyToID,
+		QuoteOfID:   quoteOfID,
+		Visibility:  vis,
+	})
+	if err != nil {
+		return database.Chirp{}, err
+	}
+	if verdict == spam.Hold {
+		err = q.HoldChirp(ctx, database.HoldChirpParams{
+			ChirpID: chirp.ID,
+			Score:   score,
+		})
+		if err != nil {
+			return database.Chirp{}, err
+		}
+	}
+	if flagged := screened.Flagged(); len(flagged) > 0 {
+		err = q.CreateChirpFlag(ctx, database.CreateChirpFlagParams{
+			ChirpID: chirp.ID,
+			Words:   flagged,
+		})
+		if err != nil {
+			return database.Chirp{}, err
+		}
+	}
+	// Mentions decide who can see a mentioned-only chirp, so they're
+	// committed along with it.
+	if len(params.Mentions) > 0 {
+		err = q.CreateMentions(ctx, database.CreateMentionsParams{
+			ChirpID:  chirp.ID,
+			UserIds:  params.Mentions,
+			AuthorID: userID,
+		})
+		if err != nil {
+			return database.Chirp{}, err
+		}
+	}
+	if len(params.MediaIDs) > 0 {
+		err = q.AttachMedia(ctx, database.AttachMediaParams{
+			ChirpID:  uuid.NullUUID{UUID: chirp.ID, Valid: true},
+			MediaIds: params.MediaIDs,
+			UserID:   userID,
+		})
+		if err != nil {
+			return database.Chirp{}, err
+		}
+	}
+	if params.Poll != nil {
+		err = q.CreatePoll(ctx, database.CreatePollParams{
+			ChirpID:         chirp.ID,
+			DurationMinutes: params.Poll.DurationMinutes,
+		})
+		if err != nil {
+			return database.Chirp{}, err
+		}
+		err = q.CreatePollOptions(ctx, database.CreatePollOptionsParams{
+			ChirpID: chirp.ID,
+			Labels:  params.Poll.Options,
+		})
+		if err != nil {
+			return database.Chirp{}, err
+		}
+	}
+	return chirp, nil
+}
+
+// publishChirp delivers a committed chirp: it fans it out to followers and
+// notifies the author of the chirp it replies to and anyone it mentions.
+func (cfg *apiConfig) publishChirp(ctx context.Context, chirp database.Chirp, mentions []uuid.UUID) {
+	cfg.fanOutChirp(ctx, chirp)
+
+	if chirp.InReplyToID.Valid {
+		parent, err := cfg.db.GetChirpByID(ctx, database.GetChirpByIDParams{
+			ID:       chirp.InReplyToID.UUID,
+			ViewerID: chirp.UserID,
+		})
+		if err == nil && cfg.canView(ctx, chirp.ID, parent.UserID) {
+			cfg.notify(ctx, parent.UserID, notificationReply, chirp.UserID, uuid.NullUUID{UUID: chirp.ID, Valid: true})
+		}
+	}
+	if len(mentions) > 0 {
+		cfg.notifyMentions(ctx, chirp)
+	}
+}
+
+// validateVisibility checks a requested visibility, defaulting to public.
+func validateVisibility(requested string) (string, error) {
+	vis, err := visibility.Parse(requested)
+	if err != nil {
+		return "", &chirpError{http.StatusBadRequest, "Visibility must be public, followers or mentioned", nil}
+	}
+	return vis, nil
+}
+
+// canView reports whether viewerID may see the chirp with chirpID.
+func (cfg *apiConfig) canView(ctx context.Context, chirpID, viewerID uuid.UUID) bool {
+	_, err := cfg.db.GetChirpByID(ctx, database.GetChirpByIDParams{
+		ID:       chirpID,
+		ViewerID: viewerID,
+	})
+	return err == nil
+}
+
+func (cfg *apiConfig) handlerCreateChirp(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "User not authorized", "err", err)
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized access", nil)
 		return
 	}
 
-	cleaned := checkProfanity(params.Body)
+	decoder := json.NewDecoder(r.Body)
+	params := chirpParams{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", nil)
+		slog.ErrorContext(r.Context(), "Couldn't decode parameters", "err", err)
+		return
+	}
 
-	chirp, err := cfg.db.CreateChirp(r.Context(), database.CreateChirpParams{
-		Body:   cleaned,
-		UserID: userID,
+	var chirp database.Chirp
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		chirp, err = cfg.createChirp(r.Context(), q, userID, params)
+		return err
 	})
+	var invalid *chirpError
+	if errors.As(err, &invalid) {
+		invalid.respond(w)
+		return
+	}
 	if err != nil {
-		log.Printf("Could not create chirp in DB: %v", err)
+		slog.ErrorContext(r.Context(), "Could not create chirp in DB", "err", err)
 		respondWithError(w, http.StatusInternalServerError, "Couldn't create chirp", nil)
 		return
 	}
-	respondWithJSON(w, http.StatusCreated, Chirp{
-		ID:        chirp.ID,
-		CreatedAt: chirp.CreatedAt,
-		UpdatedAt: chirp.UpdatedAt,
-		Body:      chirp.Body,
-		UserID:    chirp.UserID,
-	})
+	cfg.metrics.chirpsCreated.Inc()
+	cfg.publishChirp(r.Context(), chirp, params.Mentions)
+
+	resp, err := cfg.loadChirp(r.Context(), userID, chirp)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not load chirp from DB", "err", err)
+		resp = newChirp(chirp)
+	}
+	respondWithJSON(w, http.StatusCreated, resp)
 }
 
 func (cfg *apiConfig) handlerGetChirps(w http.ResponseWriter, r *http.Request) {
-	dbChirps, err := cfg.db.GetChirps(r.Context())
+	viewerID := cfg.viewerID(r)
+	dbChirps, err := cfg.db.GetChirps(r.Context(), viewerID)
 	if err != nil {
-		log.Printf("Could not retrieve chirps from DB: %v", err)
+		slog.ErrorContext(r.Context(), "Could not retrieve chirps from DB", "err", err)
 		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve chirps", nil)
 		return
 	}
-	chirps := []Chirp{}
-	for _, dbChirp := range dbChirps {
-		chirps = append(chirps, Chirp{
-			ID:        dbChirp.ID,
-			CreatedAt: dbChirp.CreatedAt,
-			UpdatedAt: dbChirp.UpdatedAt,
-			UserID:    dbChirp.UserID,
-			Body:      dbChirp.Body,
-		})
+	chirps, err := cfg.loadChirps(r.Context(), viewerID, dbChirps)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not load chirp counts from DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve chirps", nil)
+		return
 	}
 	respondWithJSON(w, http.StatusOK, chirps)
 }
@@ -94,39 +437,120 @@ func (cfg *apiConfig) handlerGetChirps(w http.ResponseWriter, r *http.Request) {
 func (cfg *apiConfig) handlerGetChirpByID(w http.ResponseWriter, r *http.Request) {
 	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
 	if err != nil {
-		log.Printf("Could not parse chirp's ID: %s", r.PathValue("chirpID"))
+		slog.InfoContext(r.Context(), "Could not parse chirp's ID", "chirp_id", r.PathValue("chirpID"))
 		respondWithError(w, http.StatusInternalServerError, "Error processing chirp's ID", nil)
 		return
 	}
-	chirp, err := cfg.db.GetChirpByID(r.Context(), chirpID)
+	viewerID := cfg.viewerID(r)
+	chirp, err := cfg.db.GetChirpByID(r.Context(), database.GetChirpByIDParams{
+		ID:       chirpID,
+		ViewerID: viewerID,
+	})
 	if err != nil {
-		log.Printf("Could not retrieve chirp from DB: %v", err)
+		slog.ErrorContext(r.Context(), "Could not retrieve chirp from DB", "err", err)
 		respondWithError(w, http.StatusNotFound, "Couldn't retrieve chirp", nil)
 		return
 	}
-	respondWithJSON(w, http.StatusOK,
-		Chirp{
-			ID:        chirp.ID,
-			CreatedAt: chirp.CreatedAt,
-			UpdatedAt: chirp.UpdatedAt,
-			UserID:    chirp.UserID,
-			Body:      chirp.Body,
-		})
+	resp, err := cfg.loadChirp(r.Context(), viewerID, chirp)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not load chirp counts from DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve chirp", nil)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, resp)
 }
 
-func checkProfanity(msg string) string {
-	profaneWords := []string{"kerfuffle", "sharbert", "fornax"}
-	words := strings.Split(msg, " ")
-	cleanedWords := make([]string, len(words))
-	for i, w := range words {
-		for _, p := range profaneWords {
-			if strings.ToLower(w) == p {
-				cleanedWords[i] = "****"
-				break
-			} else {
-				cleanedWords[i] = w
-			}
+func (cfg *apiConfig) handlerGetChirpsByAuthor(w http.ResponseWriter, r *http.Request) {
+	authorID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", nil)
+		return
+	}
+	p, err := parsePage(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	viewerID := cfg.viewerID(r)
+	dbChirps, err := cfg.db.GetChirpsByAuthor(r.Context(), database.GetChirpsByAuthorParams{
+		UserID:          authorID,
+		ViewerID:        viewerID,
+		CursorCreatedAt: p.cursorCreatedAt,
+		CursorID:        p.cursorID,
+		PageSize:        p.size,
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not retrieve chirps from DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve chirps", nil)
+		return
+	}
+	resp, err := cfg.newChirpPage(r.Context(), viewerID, p, dbChirps)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not load chirps from DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve chirps", nil)
+		return
+	}
+
+	// Pinned chirps are left out of the paged listing and shown ahead of
+	// its first page instead.
+	if r.URL.Query().Get("cursor") == "" {
+		dbPinned, err := cfg.db.GetPinnedChirps(r.Context(), database.GetPinnedChirpsParams{
+			UserID:   authorID,
+			ViewerID: viewerID,
+		})
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Could not retrieve pinned chirps from DB", "err", err)
+			respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve chirps", nil)
+			return
+		}
+		pinned, err := cfg.loadChirps(r.Context(), viewerID, dbPinned)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Could not load chirps from DB", "err", err)
+			respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve chirps", nil)
+			return
 		}
+		for i := range pinned {
+			pinned[i].Pinned = true
+		}
+		resp.Chirps = append(pinned, resp.Chirps...)
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+func (cfg *apiConfig) handlerDeleteChirp(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized access", nil)
+		return
+	}
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", nil)
+		return
+	}
+
+	chirp, err := cfg.db.GetChirpByID(r.Context(), database.GetChirpByIDParams{
+		ID:       chirpID,
+		ViewerID: userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Couldn't retrieve chirp", nil)
+		return
+	}
+	if chirp.UserID != userID {
+		respondWithError(w, http.StatusForbidden, "You can't delete this chirp", nil)
+		return
+	}
+
+	_, err = cfg.db.DeleteChirp(r.Context(), database.DeleteChirpParams{
+		ID:     chirpID,
+		UserID: userID,
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not delete chirp from DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't delete chirp", nil)
+		return
 	}
-	return strings.Join(cleanedWords, " ")
+	w.WriteHeader(http.StatusNoContent)
 }
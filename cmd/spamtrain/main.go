@@ -0,0 +1,67 @@
+// Command spamtrain retrains the spam classifier from the labels moderators
+// have given held and reported chirps, and writes the model for the server
+// to load from SPAM_MODEL on its next start.
+package main
+
+import _ "github.com/lib/pq"
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"github.com/joho/godotenv"
+	"internal/database"
+	"internal/spam"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+func main() {
+	godotenv.Load()
+	out := flag.String("out", os.Getenv("SPAM_MODEL"), "where to write the model (default $SPAM_MODEL)")
+	flag.Parse()
+	if *out == "" {
+		log.Fatal("-out or SPAM_MODEL must be set")
+	}
+
+	dbURL := os.Getenv("DB_URL")
+	if dbURL == "" {
+		log.Fatal("DB_URL must be set")
+	}
+	dbConn, err := sql.Open("postgres", dbURL)
+	if err != nil {
+		log.Fatalf("Cannot open database: %s", err)
+	}
+	defer dbConn.Close()
+
+	labels, err := database.New(dbConn).GetSpamLabels(context.Background())
+	if err != nil {
+		log.Fatalf("Cannot read spam labels: %s", err)
+	}
+	model := spam.NewModel()
+	spamCount := 0
+	for _, label := range labels {
+		model.Train(label.Body, label.Spam)
+		if label.Spam {
+			spamCount++
+		}
+	}
+
+	// Written alongside and renamed into place, so a server starting
+	// meanwhile never reads half a model.
+	tmp, err := os.CreateTemp(filepath.Dir(*out), ".spam-model-*")
+	if err != nil {
+		log.Fatalf("Cannot write model: %s", err)
+	}
+	defer os.Remove(tmp.Name())
+	if err := model.Save(tmp); err != nil {
+		log.Fatalf("Cannot write model: %s", err)
+	}
+	if err := tmp.Close(); err != nil {
+		log.Fatalf("Cannot write model: %s", err)
+	}
+	if err := os.Rename(tmp.Name(), *out); err != nil {
+		log.Fatalf("Cannot write model: %s", err)
+	}
+	log.Printf("Trained on %d labels (%d spam), wrote %s", len(labels), spamCount, *out)
+}
@@ -0,0 +1,464 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"internal/content"
+	"internal/database"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// maxConversationMembers counts the creator, so a group holds them and
+	// up to nine others.
+	maxConversationMembers = 10
+	maxDirectMessageLength = 1000
+
+	// Each user may send at most directMessageRateLimit messages in any
+	// directMessageRateWindow, across all of their conversations.
+	directMessageRateLimit  = 30
+	directMessageRateWindow = time.Minute
+)
+
+var errTooManyMessages = errors.New("too many recent messages")
+
+type ConversationMember struct {
+	UserID     uuid.UUID  `json:"user_id"`
+	JoinedAt   time.Time  `json:"joined_at"`
+	LastReadAt *time.Time `json:"last_read_at,omitempty"`
+}
+
+type Conversation struct {
+	ID          uuid.UUID            `json:"id"`
+	CreatedAt   time.Time            `json:"created_at"`
+	UpdatedAt   time.Time            `json:"updated_at"`
+	IsGroup     bool                 `json:"is_group"`
+	Members     []ConversationMember `json:"members"`
+	UnreadCount int64                `json:"unread_count"`
+}
+
+type ConversationPage struct {
+	Conversations []Conversation `json:"conversations"`
+	NextCursor    string         `json:"next_cursor,omitempty"`
+}
+
+type DirectMessage struct {
+	ID             uuid.UUID `json:"id"`
+	CreatedAt      time.Time `json:"created_at"`
+	ConversationID uuid.UUID `json:"conversation_id"`
+	SenderID       uuid.UUID `json:"sender_id"`
+	Body           string    `json:"body"`
+}
+
+type DirectMessagePage struct {
+	Messages   []DirectMessage `json:"messages"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
+
+func newDirectMessage(dbMessage database.DirectMessage) DirectMessage {
+	return DirectMessage{
+		ID:             dbMessage.ID,
+		CreatedAt:      dbMessage.CreatedAt,
+		ConversationID: dbMessage.ConversationID,
+		SenderID:       dbMessage.SenderID,
+		Body:           dbMessage.Body,
+	}
+}
+
+// directKey identifies the one-to-one conversation between two users, whichever
+// of them starts it.
+func directKey(a, b uuid.UUID) string {
+	ids := []string{a.String(), b.String()}
+	slices.Sort(ids)
+	return strings.Join(ids, ":")
+}
+
+// newConversations converts conversation rows, attaching their members and
+// read receipts in one query.
+func (cfg *apiConfig) newConversations(r *http.Request, rows []database.GetConversationsRow) ([]Conversation, error) {
+	ids := make([]uuid.UUID, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, row.ID)
+	}
+	dbMembers, err := cfg.db.GetConversationMembers(r.Context(), ids)
+	if err != nil {
+		return nil, err
+	}
+	members := map[uuid.UUID][]ConversationMember{}
+	for _, dbMember := range dbMembers {
+		member := ConversationMember{UserID: dbMember.UserID, JoinedAt: dbMember.JoinedAt}
+		if dbMember.LastReadAt.Valid {
+			member.LastReadAt = &dbMember.LastReadAt.Time
+		}
+		members[dbMember.ConversationID] = append(members[dbMember.ConversationID], member)
+	}
+
+	conversations := make([]Conversation, 0, len(rows))
+	for _, row := range rows {
+		conversations = append(conversations, Conversation{
+			ID:          row.ID,
+			CreatedAt:   row.CreatedAt,
+			UpdatedAt:   row.UpdatedAt,
+			IsGroup:     !row.DirectKey.Valid,
+			Members:     members[row.ID],
+			UnreadCount: row.UnreadCount,
+		})
+	}
+	return conversations, nil
+}
+
+// memberConversation authenticates the request and resolves the
+// {conversationID} it acts on. Conversations the user isn't a member of are
+// reported as not found. It writes the error response itself and reports
+// whether to continue.
+func (cfg *apiConfig) memberConversation(w http.ResponseWriter, r *http.Request) (userID uuid.UUID, conversation database.GetConversationRow, ok bool) {
+	userID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized access", nil)
+		return uuid.Nil, conversation, false
+	}
+	conversationID, err := uuid.Parse(r.PathValue("conversationID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid conversation ID", nil)
+		return uuid.Nil, conversation, false
+	}
+	conversation, err = cfg.db.GetConversation(r.Context(), database.GetConversationParams{
+		UserID: userID,
+		ID:     conversationID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Couldn't find conversation", nil)
+		return uuid.Nil, conversation, false
+	}
+	return userID, conversation, true
+}
+
+func (cfg *apiConfig) handlerCreateConversation(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		MemberIDs []uuid.UUID `json:"member_ids"`
+	}
+	userID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized access", nil)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", nil)
+		return
+	}
+
+	others := []uuid.UUID{}
+	for _, id := range params.MemberIDs {
+		if id != userID && !slices.Contains(others, id) {
+			others = append(others, id)
+		}
+	}
+	if len(others) == 0 {
+		respondWithError(w, http.StatusBadRequest, "A conversation needs at least one other member", nil)
+		return
+	}
+	if len(others)+1 > maxConversationMembers {
+		respondWithError(w, http.StatusBadRequest, "Too many members", nil)
+		return
+	}
+
+	found, err := cfg.db.CountUsersByIDs(r.Context(), others)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not count users in DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create conversation", nil)
+		return
+	}
+	if found != int64(len(others)) {
+		respondWithError(w, http.StatusNotFound, "Couldn't find user", nil)
+		return
+	}
+	blocked, err := cfg.db.IsBlockedWithAny(r.Context(), database.IsBlockedWithAnyParams{
+		UserIds: others,
+		UserID:  userID,
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not check blocks in DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create conversation", nil)
+		return
+	}
+	if blocked {
+		respondWithError(w, http.StatusForbidden, "You can't message one or more of these users", nil)
+		return
+	}
+
+	// Starting a one-to-one conversation that already exists returns it.
+	key := sql.NullString{}
+	if len(others) == 1 {
+		key = sql.NullString{String: directKey(userID, others[0]), Valid: true}
+	}
+	status := http.StatusCreated
+	dbConversation, err := cfg.db.CreateConversation(r.Context(), key)
+	if errors.Is(err, sql.ErrNoRows) {
+		status = http.StatusOK
+		dbConversation, err = cfg.db.GetConversationByDirectKey(r.Context(), key)
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not create conversation in DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create conversation", nil)
+		return
+	}
+
+	err = cfg.db.AddConversationMembers(r.Context(), database.AddConversationMembersParams{
+		ConversationID: dbConversation.ID,
+		UserIds:        append(others, userID),
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not add conversation members in DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create conversation", nil)
+		return
+	}
+
+	row, err := cfg.db.GetConversation(r.Context(), database.GetConversationParams{
+		UserID: userID,
+		ID:     dbConversation.ID,
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not retrieve conversation from DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create conversation", nil)
+		return
+	}
+	conversations, err := cfg.newConversations(r, []database.GetConversationsRow{database.GetConversationsRow(row)})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not load conversation members from DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create conversation", nil)
+		return
+	}
+	respondWithJSON(w, status, conversations[0])
+}
+
+func (cfg *apiConfig) handlerGetConversations(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized access", nil)
+		return
+	}
+	p, err := parsePage(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	rows, err := cfg.db.GetConversations(r.Context(), database.GetConversationsParams{
+		UserID:          userID,
+		CursorUpdatedAt: p.cursorCreatedAt,
+		CursorID:        p.cursorID,
+		PageSize:        p.size,
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not retrieve conversations from DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve conversations", nil)
+		return
+	}
+	conversations, err := cfg.newConversations(r, rows)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not load conversation members from DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve conversations", nil)
+		return
+	}
+
+	resp := ConversationPage{Conversations: conversations}
+	if len(rows) > 0 {
+		last := rows[len(rows)-1]
+		resp.NextCursor = p.nextCursor(len(rows), last.UpdatedAt, last.ID)
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+func (cfg *apiConfig) handlerGetConversation(w http.ResponseWriter, r *http.Request) {
+	_, row, ok := cfg.memberConversation(w, r)
+	if !ok {
+		return
+	}
+	conversations, err := cfg.newConversations(r, []database.GetConversationsRow{database.GetConversationsRow(row)})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not load conversation members from DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve conversation", nil)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, conversations[0])
+}
+
+func (cfg *apiConfig) handlerSendDirectMessage(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Body string `json:"body"`
+	}
+	userID, conversation, ok := cfg.memberConversation(w, r)
+	if !ok {
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", nil)
+		return
+	}
+	body, err := content.Check(params.Body, maxDirectMessageLength)
+	var tooLong *content.LengthError
+	if errors.As(err, &tooLong) {
+		respondWithLengthError(w, "Message is too long", tooLong)
+		return
+	}
+	if body == "" {
+		respondWithError(w, http.StatusBadRequest, "Message is empty", nil)
+		return
+	}
+
+	// A block ends a one-to-one conversation. In a group the blocked pair
+	// can still both post, but neither sees the other's messages.
+	if conversation.DirectKey.Valid {
+		blocked, err := cfg.db.IsBlockedInConversation(r.Context(), database.IsBlockedInConversationParams{
+			ConversationID: conversation.ID,
+			UserID:         userID,
+		})
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Could not check blocks in DB", "err", err)
+			respondWithError(w, http.StatusInternalServerError, "Couldn't send message", nil)
+			return
+		}
+		if blocked {
+			respondWithError(w, http.StatusForbidden, "You can't message this user", nil)
+			return
+		}
+	}
+
+	var message database.DirectMessage
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		// Held until commit, so concurrent sends can't all pass the count.
+		if err := q.LockUser(r.Context(), userID); err != nil {
+			return err
+		}
+		sent, err := q.CountRecentDirectMessages(r.Context(), database.CountRecentDirectMessagesParams{
+			SenderID:  userID,
+			CreatedAt: time.Now().Add(-directMessageRateWindow),
+		})
+		if err != nil {
+			return err
+		}
+		if sent >= directMessageRateLimit {
+			return errTooManyMessages
+		}
+		message, err = q.CreateDirectMessage(r.Context(), database.CreateDirectMessageParams{
+			ConversationID: conversation.ID,
+			SenderID:       userID,
+			Body:           body,
+		})
+		return err
+	})
+	if errors.Is(err, errTooManyMessages) {
+		w.Header().Set("Retry-After", strconv.Itoa(int(directMessageRateWindow.Seconds())))
+		respondWithError(w, http.StatusTooManyRequests, "Too many messages, try again later", nil)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not create message in DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't send message", nil)
+		return
+	}
+
+	err = cfg.db.TouchConversation(r.Context(), database.TouchConversationParams{
+		ID:        conversation.ID,
+		UpdatedAt: message.CreatedAt,
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not update conversation in DB", "err", err)
+	}
+	err = cfg.db.MarkConversationRead(r.Context(), database.MarkConversationReadParams{
+		ReadAt:         message.CreatedAt,
+		ConversationID: conversation.ID,
+		UserID:         userID,
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not update read receipt in DB", "err", err)
+	}
+	respondWithJSON(w, http.StatusCreated, newDirectMessage(message))
+}
+
+func (cfg *apiConfig) handlerGetDirectMessages(w http.ResponseWriter, r *http.Request) {
+	userID, conversation, ok := cfg.memberConversation(w, r)
+	if !ok {
+		return
+	}
+	p, err := parsePage(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	dbMessages, err := cfg.db.GetDirectMessages(r.Context(), database.GetDirectMessagesParams{
+		ConversationID:  conversation.ID,
+		CursorCreatedAt: p.cursorCreatedAt,
+		CursorID:        p.cursorID,
+		ViewerID:        userID,
+		PageSize:        p.size,
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not retrieve messages from DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve messages", nil)
+		return
+	}
+
+	resp := DirectMessagePage{Messages: []DirectMessage{}}
+	for _, dbMessage := range dbMessages {
+		resp.Messages = append(resp.Messages, newDirectMessage(dbMessage))
+	}
+	if len(dbMessages) > 0 {
+		last := dbMessages[len(dbMessages)-1]
+		resp.NextCursor = p.nextCursor(len(dbMessages), last.CreatedAt, last.ID)
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+// handlerMarkConversationRead moves the user's read receipt up to the given
+// message. Receipts never move backwards.
+func (cfg *apiConfig) handlerMarkConversationRead(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		MessageID uuid.UUID `json:"message_id"`
+	}
+	userID, conversation, ok := cfg.memberConversation(w, r)
+	if !ok {
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", nil)
+		return
+	}
+	message, err := cfg.db.GetDirectMessage(r.Context(), database.GetDirectMessageParams{
+		ID:             params.MessageID,
+		ConversationID: conversation.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Couldn't find message", nil)
+		return
+	}
+
+	err = cfg.db.MarkConversationRead(r.Context(), database.MarkConversationReadParams{
+		ReadAt:         message.CreatedAt,
+		ConversationID: conversation.ID,
+		UserID:         userID,
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not update read receipt in DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't mark conversation read", nil)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
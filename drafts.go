@@ -0,0 +1,436 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"internal/database"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// schedulerInterval is how often each server checks for scheduled drafts
+// that are due.
+const schedulerInterval = 10 * time.Second
+
+type Draft struct {
+	ID          uuid.UUID   `json:"id"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+	Body        string      `json:"body"`
+	InReplyToID *uuid.UUID  `json:"in_reply_to_id,omitempty"`
+	QuoteOfID   *uuid.UUID  `json:"quote_of_id,omitempty"`
+	Mentions    []uuid.UUID `json:"mentions"`
+	MediaIDs    []uuid.UUID `json:"media_ids"`
+	Poll        *pollParams `json:"poll,omitempty"`
+	Visibility  string      `json:"visibility"`
+	PublishAt   *time.Time  `json:"publish_at,omitempty"`
+	Failure     string      `json:"failure,omitempty"`
+}
+
+type DraftPage struct {
+	Drafts     []Draft `json:"drafts"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+}
+
+func newDraft(dbDraft database.Draft) Draft {
+	draft := Draft{
+		ID:         dbDraft.ID,
+		CreatedAt:  dbDraft.CreatedAt,
+		UpdatedAt:  dbDraft.UpdatedAt,
+		Body:       dbDraft.Body,
+		Mentions:   dbDraft.Mentions,
+		MediaIDs:   dbDraft.MediaIds,
+		Visibility: dbDraft.Visibility,
+		Failure:    dbDraft.Failure.String,
+	}
+	if dbDraft.InReplyToID.Valid {
+		draft.InReplyToID = &dbDraft.InReplyToID.UUID
+	}
+	if dbDraft.QuoteOfID.Valid {
+		draft.QuoteOfID = &dbDraft.QuoteOfID.UUID
+	}
+	if dbDraft.PublishAt.Valid {
+		draft.PublishAt = &dbDraft.PublishAt.Time
+	}
+	if draft.Mentions == nil {
+		draft.Mentions = []uuid.UUID{}
+	}
+	if draft.MediaIDs == nil {
+		draft.MediaIDs = []uuid.UUID{}
+	}
+	draft.Poll = draftPoll(dbDraft)
+	return draft
+}
+
+// draftChirpParams is the chirp a draft publishes as.
+func draftChirpParams(dbDraft database.Draft) chirpParams {
+	params := chirpParams{
+		Body:       dbDraft.Body,
+		Mentions:   dbDraft.Mentions,
+		MediaIDs:   dbDraft.MediaIds,
+		Visibility: dbDraft.Visibility,
+	}
+	if dbDraft.InReplyToID.Valid {
+		params.InReplyToID = &dbDraft.InReplyToID.UUID
+	}
+	if dbDraft.QuoteOfID.Valid {
+		params.QuoteOfID = &dbDraft.QuoteOfID.UUID
+	}
+	params.Poll = draftPoll(dbDraft)
+	return params
+}
+
+func draftPoll(dbDraft database.Draft) *pollParams {
+	if len(dbDraft.PollOptions) == 0 {
+		return nil
+	}
+	return &pollParams{
+		Options:         dbDraft.PollOptions,
+		DurationMinutes: dbDraft.PollDurationMinutes.Int32,
+	}
+}
+
+// draftPollColumns splits a draft's poll into the columns it's stored in.
+func draftPollColumns(poll *pollParams) ([]string, sql.NullInt32) {
+	if poll == nil {
+		return []string{}, sql.NullInt32{}
+	}
+	return poll.Options, sql.NullInt32{Int32: poll.DurationMinutes, Valid: true}
+}
+
+type draftParams struct {
+	chirpParams
+	PublishAt *time.Time `json:"publish_at"`
+}
+
+// decodeDraft reads and checks a draft from the request body. Drafts are only
+// fully validated when they're published, since what they reply to or quote
+// can change in the meantime.
+func decodeDraft(w http.ResponseWriter, r *http.Request) (draftParams, sql.NullTime, bool) {
+	decoder := json.NewDecoder(r.Body)
+	params := draftParams{}
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", nil)
+		return draftParams{}, sql.NullTime{}, false
+	}
+	if len(params.Mentions) > maxChirpMentions {
+		respondWithError(w, http.StatusBadRequest, "Too many mentions", nil)
+		return draftParams{}, sql.NullTime{}, false
+	}
+	if len(params.MediaIDs) > maxChirpMedia {
+		respondWithError(w, http.StatusBadRequest, "Too many attachments", nil)
+		return draftParams{}, sql.NullTime{}, false
+	}
+	var invalid *chirpError
+	if params.Poll != nil {
+		if err := validatePoll(params.Poll); errors.As(err, &invalid) {
+			invalid.respond(w)
+			return draftParams{}, sql.NullTime{}, false
+		}
+	}
+	vis, err := validateVisibility(params.Visibility)
+	if errors.As(err, &invalid) {
+		invalid.respond(w)
+		return draftParams{}, sql.NullTime{}, false
+	}
+	params.Visibility = vis
+	if params.Mentions == nil {
+		params.Mentions = []uuid.UUID{}
+	}
+	if params.MediaIDs == nil {
+		params.MediaIDs = []uuid.UUID{}
+	}
+	publishAt := sql.NullTime{}
+	if params.PublishAt != nil {
+		if !params.PublishAt.After(time.Now()) {
+			respondWithError(w, http.StatusBadRequest, "publish_at must be in the future", nil)
+			return draftParams{}, sql.NullTime{}, false
+		}
+		publishAt = sql.NullTime{Time: params.PublishAt.UTC(), Valid: true}
+	}
+	return params, publishAt, true
+}
+
+func nullUUID(id *uuid.UUID) uuid.NullUUID {
+	if id == nil {
+		return uuid.NullUUID{}
+	}
+	return uuid.NullUUID{UUID: *id, Valid: true}
+}
+
+// publishDraft claims a draft with claim and publishes it as a chirp in the
+// same transaction that deletes it, so a draft becomes exactly one chirp even
+// when several servers race for it. A draft that fails validation is kept
+// with the reason, and the *chirpError is returned.
+func (cfg *apiConfig) publishDraft(ctx context.Context, claim func(q *database.Queries) (database.Draft, error)) (database.Chirp, error) {
+	var dbDraft database.Draft
+	var chirp database.Chirp
+	var invalid *chirpError
+	err := cfg.withTx(ctx, func(q *database.Queries) error {
+		var err error
+		dbDraft, err = claim(q)
+		if err != nil {
+			return err
+		}
+		chirp, err = cfg.createChirp(ctx, q, dbDraft.UserID, draftChirpParams(dbDraft))
+		if errors.As(err, &invalid) {
+			return q.FailDraft(ctx, database.FailDraftParams{
+				ID:      dbDraft.ID,
+				Failure: sql.NullString{String: invalid.msg, Valid: true},
+			})
+		}
+		if err != nil {
+			return err
+		}
+		_, err = q.DeleteDraft(ctx, database.DeleteDraftParams{
+			ID:     dbDraft.ID,
+			UserID: dbDraft.UserID,
+		})
+		return err
+	})
+	if err != nil {
+		return database.Chirp{}, err
+	}
+	if invalid != nil {
+		return database.Chirp{}, invalid
+	}
+	cfg.metrics.chirpsCreated.Inc()
+	cfg.publishChirp(ctx, chirp, dbDraft.Mentions)
+	return chirp, nil
+}
+
+// runScheduler publishes scheduled drafts as they fall due. Every replica
+// runs it; SKIP LOCKED claims keep them from publishing the same draft.
+func (cfg *apiConfig) runScheduler(ctx context.Context) {
+	ticker := time.NewTicker(schedulerInterval)
+	defer ticker.Stop()
+	for {
+		for {
+			_, err := cfg.publishDraft(ctx, func(q *database.Queries) (database.Draft, error) {
+				return q.ClaimDueDraft(ctx)
+			})
+			var invalid *chirpError
+			if errors.Is(err, sql.ErrNoRows) {
+				break
+			}
+			if err != nil && !errors.As(err, &invalid) {
+				slog.ErrorContext(ctx, "Could not publish scheduled draft", "err", err)
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (cfg *apiConfig) handlerCreateDraft(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized access", nil)
+		return
+	}
+	params, publishAt, ok := decodeDraft(w, r)
+	if !ok {
+		return
+	}
+
+	pollOptions, pollDuration := draftPollColumns(params.Poll)
+	dbDraft, err := cfg.db.CreateDraft(r.Context(), database.CreateDraftParams{
+		UserID:              userID,
+		Body:                params.Body,
+		InReplyToID:         nullUUID(params.InReplyToID),
+		QuoteOfID:           nullUUID(params.QuoteOfID),
+		Mentions:            params.Mentions,
+		MediaIds:            params.MediaIDs,
+		PublishAt:           publishAt,
+		PollOptions:         pollOptions,
+		PollDurationMinutes: pollDuration,
+		Visibility:          params.Visibility,
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not create draft in DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't save draft", nil)
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, newDraft(dbDraft))
+}
+
+func (cfg *apiConfig) handlerGetDrafts(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized access", nil)
+		return
+	}
+	p, err := parsePage(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	dbDrafts, err := cfg.db.GetDrafts(r.Context(), database.GetDraftsParams{
+		UserID:          userID,
+		CursorUpdatedAt: p.cursorCreatedAt,
+		CursorID:        p.cursorID,
+		PageSize:        p.size,
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not retrieve drafts from DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve drafts", nil)
+		return
+	}
+
+	resp := DraftPage{Drafts: []Draft{}}
+	for _, dbDraft := range dbDrafts {
+		resp.Drafts = append(resp.Drafts, newDraft(dbDraft))
+	}
+	if len(dbDrafts) > 0 {
+		last := dbDrafts[len(dbDrafts)-1]
+		resp.NextCursor = p.nextCursor(len(dbDrafts), last.UpdatedAt, last.ID)
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+func (cfg *apiConfig) handlerGetDraft(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized access", nil)
+		return
+	}
+	draftID, err := uuid.Parse(r.PathValue("draftID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid draft ID", nil)
+		return
+	}
+
+	dbDraft, err := cfg.db.GetDraft(r.Context(), database.GetDraftParams{
+		ID:     draftID,
+		UserID: userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Couldn't find draft", nil)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, newDraft(dbDraft))
+}
+
+func (cfg *apiConfig) handlerUpdateDraft(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized access", nil)
+		return
+	}
+	draftID, err := uuid.Parse(r.PathValue("draftID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid draft ID", nil)
+		return
+	}
+	params, publishAt, ok := decodeDraft(w, r)
+	if !ok {
+		return
+	}
+
+	pollOptions, pollDuration := draftPollColumns(params.Poll)
+	dbDraft, err := cfg.db.UpdateDraft(r.Context(), database.UpdateDraftParams{
+		ID:                  draftID,
+		UserID:              userID,
+		Body:                params.Body,
+		InReplyToID:         nullUUID(params.InReplyToID),
+		QuoteOfID:           nullUUID(params.QuoteOfID),
+		Mentions:            params.Mentions,
+		MediaIds:            params.MediaIDs,
+		PublishAt:           publishAt,
+		PollOptions:         pollOptions,
+		PollDurationMinutes: pollDuration,
+		Visibility:          params.Visibility,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Couldn't find draft", nil)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not update draft in DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't save draft", nil)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, newDraft(dbDraft))
+}
+
+func (cfg *apiConfig) handlerDeleteDraft(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized access", nil)
+		return
+	}
+	draftID, err := uuid.Parse(r.PathValue("draftID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid draft ID", nil)
+		return
+	}
+
+	deleted, err := cfg.db.DeleteDraft(r.Context(), database.DeleteDraftParams{
+		ID:     draftID,
+		UserID: userID,
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not delete draft from DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't delete draft", nil)
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "Couldn't find draft", nil)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerPublishDraft publishes a draft immediately, scheduled or not.
+func (cfg *apiConfig) handlerPublishDraft(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized access", nil)
+		return
+	}
+	draftID, err := uuid.Parse(r.PathValue("draftID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid draft ID", nil)
+		return
+	}
+
+	chirp, err := cfg.publishDraft(r.Context(), func(q *database.Queries) (database.Draft, error) {
+		return q.ClaimDraft(r.Context(), database.ClaimDraftParams{
+			ID:     draftID,
+			UserID: userID,
+		})
+	})
+	var invalid *chirpError
+	if errors.As(err, &invalid) {
+		invalid.respond(w)
+		return
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		// Either there's no such draft or the scheduler is publishing it
+		// right now; in both cases it won't be here in a moment.
+		respondWithError(w, http.StatusNotFound, "Couldn't find draft", nil)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not publish draft", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't publish draft", nil)
+		return
+	}
+
+	resp, err := cfg.loadChirp(r.Context(), userID, chirp)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not load chirp from DB", "err", err)
+		resp = newChirp(chirp)
+	}
+	respondWithJSON(w, http.StatusCreated, resp)
+}
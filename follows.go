@@ -0,0 +1,177 @@
+package main
+
+import (
+	"github.com/google/uuid"
+	"internal/database"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// Follow is one entry in a follower or following listing.
+type Follow struct {
+	UserID     uuid.UUID `json:"user_id"`
+	FollowedAt time.Time `json:"followed_at"`
+}
+
+type FollowPage struct {
+	Users      []Follow `json:"users"`
+	NextCursor string   `json:"next_cursor,omitempty"`
+}
+
+// timelineBackfillSize is how many of a followee's recent chirps are copied
+// into the follower's home timeline when the follow is created.
+const timelineBackfillSize = 50
+
+func (cfg *apiConfig) handlerFollowUser(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized access", nil)
+		return
+	}
+
+	followeeID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", nil)
+		return
+	}
+	if followeeID == userID {
+		respondWithError(w, http.StatusBadRequest, "You can't follow yourself", nil)
+		return
+	}
+
+	_, err = cfg.db.GetUserForViewer(r.Context(), database.GetUserForViewerParams{
+		ID:       followeeID,
+		ViewerID: userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Couldn't find user", nil)
+		return
+	}
+
+	created, err := cfg.db.CreateFollow(r.Context(), database.CreateFollowParams{
+		FollowerID: userID,
+		FolloweeID: followeeID,
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not create follow in DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't follow user", nil)
+		return
+	}
+
+	if created > 0 {
+		err = cfg.db.BackfillTimeline(r.Context(), database.BackfillTimelineParams{
+			UserID:       userID,
+			FolloweeID:   followeeID,
+			BackfillSize: timelineBackfillSize,
+		})
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Could not backfill timeline", "err", err)
+		}
+		cfg.notify(r.Context(), followeeID, notificationFollow, userID, uuid.NullUUID{})
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerUnfollowUser(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized access", nil)
+		return
+	}
+
+	followeeID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", nil)
+		return
+	}
+
+	_, err = cfg.db.DeleteFollow(r.Context(), database.DeleteFollowParams{
+		FollowerID: userID,
+		FolloweeID: followeeID,
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not delete follow in DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't unfollow user", nil)
+		return
+	}
+
+	err = cfg.db.PruneTimeline(r.Context(), database.PruneTimelineParams{
+		UserID:     userID,
+		FolloweeID: followeeID,
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not prune timeline", "err", err)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerGetFollowers(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", nil)
+		return
+	}
+	p, err := parsePage(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	rows, err := cfg.db.GetFollowers(r.Context(), database.GetFollowersParams{
+		UserID:          userID,
+		CursorCreatedAt: p.cursorCreatedAt,
+		CursorID:        p.cursorID,
+		PageSize:        p.size,
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not retrieve followers from DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve followers", nil)
+		return
+	}
+
+	resp := FollowPage{Users: []Follow{}}
+	for _, row := range rows {
+		resp.Users = append(resp.Users, Follow{UserID: row.UserID, FollowedAt: row.CreatedAt})
+	}
+	if len(rows) > 0 {
+		last := rows[len(rows)-1]
+		resp.NextCursor = p.nextCursor(len(rows), last.CreatedAt, last.UserID)
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+func (cfg *apiConfig) handlerGetFollowing(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", nil)
+		return
+	}
+	p, err := parsePage(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	rows, err := cfg.db.GetFollowing(r.Context(), database.GetFollowingParams{
+		UserID:          userID,
+		CursorCreatedAt: p.cursorCreatedAt,
+		CursorID:        p.cursorID,
+		PageSize:        p.size,
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not retrieve following from DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve following", nil)
+		return
+	}
+
+	resp := FollowPage{Users: []Follow{}}
+	for _, row := range rows {
+		resp.Users = append(resp.Users, Follow{UserID: row.UserID, FollowedAt: row.CreatedAt})
+	}
+	if len(rows) > 0 {
+		last := rows[len(rows)-1]
+		resp.NextCursor = p.nextCursor(len(rows), last.CreatedAt, last.UserID)
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
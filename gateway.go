@@ -0,0 +1,463 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"internal/auth"
+	"internal/database"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	gatewayWriteWait      = 10 * time.Second
+	gatewayPongWait       = 60 * time.Second
+	gatewayPingPeriod     = gatewayPongWait * 9 / 10
+	gatewaySendQueue      = 32
+	gatewayMaxMessageSize = 4096
+
+	// Close code sent when the connection's token expires or is revoked.
+	// Clients should refresh their token and reconnect.
+	gatewayCloseUnauthorized = 4001
+)
+
+const (
+	topicHome          = "home"
+	topicNotifications = "notifications"
+	topicThread        = "thread"
+)
+
+var errSessionRevoked = errors.New("token was issued before the user's sessions were revoked")
+
+var gatewayUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+}
+
+// gatewayRequest is a message from the client. Type is one of "subscribe",
+// "unsubscribe" or "auth"; the last swaps in a fresh token so the connection
+// outlives the one it was opened with.
+type gatewayRequest struct {
+	Type    string    `json:"type"`
+	Topic   string    `json:"topic"`
+	ChirpID uuid.UUID `json:"chirp_id"`
+	Token   string    `json:"token"`
+}
+
+type gatewayMessage struct {
+	Type    string     `json:"type"`
+	Topic   string     `json:"topic,omitempty"`
+	ChirpID *uuid.UUID `json:"chirp_id,omitempty"`
+	Chirp   *Chirp     `json:"chirp,omitempty"`
+	Unread  *int64     `json:"unread,omitempty"`
+	Error   string     `json:"error,omitempty"`
+}
+
+type gatewayConn struct {
+	cfg    *apiConfig
+	ws     *websocket.Conn
+	userID uuid.UUID
+	send   chan []byte
+	cancel context.CancelFunc
+
+	mu            sync.Mutex
+	expiresAt     time.Time
+	home          bool
+	notifications bool
+	threads       map[uuid.UUID]bool
+
+	closeOnce sync.Once
+}
+
+func (cfg *apiConfig) handlerGateway(w http.ResponseWriter, r *http.Request) {
+	// Browsers can't set headers on a WebSocket handshake, so the token may
+	// also come in the query string.
+	token := r.URL.Query().Get("access_token")
+	if header := r.Header.Get("Authorization"); header != "" {
+		token, _ = strings.CutPrefix(header, "Bearer ")
+	}
+	claims, err := cfg.authenticateGateway(r.Context(), token)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized access", err)
+		return
+	}
+
+	ws, err := gatewayUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not upgrade gateway connection", "err", err)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &gatewayConn{
+		cfg:       cfg,
+		ws:        ws,
+		userID:    claims.UserID,
+		send:      make(chan []byte, gatewaySendQueue),
+		cancel:    cancel,
+		expiresAt: claims.ExpiresAt,
+		threads:   map[uuid.UUID]bool{},
+	}
+	cfg.gateway.add(c)
+	defer cfg.gateway.remove(c)
+	go c.readPump(ctx)
+	c.run(ctx)
+}
+
+// authenticateGateway validates an access token for a realtime connection.
+// Unlike a single request, a connection can outlive a ban or a logout, so
+// the token is refused if the account is restricted or was issued before the
+// user's sessions were last revoked. Tokens only record the second they were
+// issued, so one issued in the same second as a revocation is refused too.
+func (cfg *apiConfig) authenticateGateway(ctx context.Context, token string) (auth.AccessClaims, error) {
+	claims, err := auth.ValidateAccessToken(token, cfg.secretToken)
+	if err != nil {
+		return auth.AccessClaims{}, err
+	}
+	user, err := cfg.db.GetUserByID(ctx, claims.UserID)
+	if err != nil {
+		return auth.AccessClaims{}, err
+	}
+	if restricted := accountRestriction(user); restricted != "" {
+		return auth.AccessClaims{}, errors.New(restricted)
+	}
+	revokedAt, err := cfg.db.GetSessionsRevokedAt(ctx, claims.UserID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return auth.AccessClaims{}, err
+	}
+	if err == nil && claims.IssuedAt.Before(revokedAt) {
+		return auth.AccessClaims{}, errSessionRevoked
+	}
+	return claims, nil
+}
+
+// close sends a close frame with the given code and tears the connection down.
+func (c *gatewayConn) close(code int, reason string) {
+	c.closeOnce.Do(func() {
+		msg := websocket.FormatCloseMessage(code, reason)
+		c.ws.WriteControl(websocket.CloseMessage, msg, time.Now().Add(gatewayWriteWait))
+		c.cancel()
+		c.ws.Close()
+	})
+}
+
+// enqueue queues a message for the client. A client that isn't keeping up is
+// disconnected instead of being allowed to buffer without bound.
+func (c *gatewayConn) enqueue(msg gatewayMessage) {
+	dat, err := json.Marshal(msg)
+	if err != nil {
+		slog.Error("Error marshalling gateway message", "err", err)
+		return
+	}
+	select {
+	case c.send <- dat:
+	default:
+		go c.close(websocket.CloseTryAgainLater, "slow consumer")
+	}
+}
+
+func (c *gatewayConn) readPump(ctx context.Context) {
+	defer c.close(websocket.CloseNormalClosure, "")
+	c.ws.SetReadLimit(gatewayMaxMessageSize)
+	c.ws.SetReadDeadline(time.Now().Add(gatewayPongWait))
+	c.ws.SetPongHandler(func(string) error {
+		return c.ws.SetReadDeadline(time.Now().Add(gatewayPongWait))
+	})
+
+	for {
+		var req gatewayRequest
+		if err := c.ws.ReadJSON(&req); err != nil {
+			return
+		}
+		c.handleRequest(ctx, req)
+	}
+}
+
+func (c *gatewayConn) handleRequest(ctx context.Context, req gatewayRequest) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch req.Type {
+	case "auth":
+		claims, err := c.cfg.authenticateGateway(ctx, req.Token)
+		if err != nil || claims.UserID != c.userID {
+			c.enqueue(gatewayMessage{Type: "error", Error: "Invalid token"})
+			return
+		}
+		c.expiresAt = claims.ExpiresAt
+	case "subscribe", "unsubscribe":
+		on := req.Type == "subscribe"
+		switch req.Topic {
+		case topicHome:
+			c.home = on
+		case topicNotifications:
+			c.notifications = on
+		case topicThread:
+			_, err := c.cfg.db.GetChirpByID(ctx, database.GetChirpByIDParams{
+				ID:       req.ChirpID,
+				ViewerID: c.userID,
+			})
+			if on && err != nil {
+				c.enqueue(gatewayMessage{Type: "error", Error: "Couldn't retrieve chirp"})
+				return
+			}
+			if on {
+				c.threads[req.ChirpID] = true
+			} else {
+				delete(c.threads, req.ChirpID)
+			}
+		default:
+			c.enqueue(gatewayMessage{Type: "error", Error: "Unknown topic"})
+			return
+		}
+	default:
+		c.enqueue(gatewayMessage{Type: "error", Error: "Unknown message type"})
+		return
+	}
+	c.enqueue(gatewayMessage{Type: "ok"})
+}
+
+func (c *gatewayConn) run(ctx context.Context) {
+	events := c.cfg.realtime.subscribe()
+	defer c.cfg.realtime.unsubscribe(events)
+
+	ping := time.NewTicker(gatewayPingPeriod)
+	defer ping.Stop()
+	c.mu.Lock()
+	expiry := time.NewTimer(time.Until(c.expiresAt))
+	c.mu.Unlock()
+	defer expiry.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case dat := <-c.send:
+			c.ws.SetWriteDeadline(time.Now().Add(gatewayWriteWait))
+			if err := c.ws.WriteMessage(websocket.TextMessage, dat); err != nil {
+				c.close(websocket.CloseAbnormalClosure, "")
+				return
+			}
+		case <-ping.C:
+			err := c.ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(gatewayWriteWait))
+			if err != nil {
+				c.close(websocket.CloseAbnormalClosure, "")
+				return
+			}
+		case <-expiry.C:
+			// The client may have re-authenticated since the timer was set.
+			c.mu.Lock()
+			remaining := time.Until(c.expiresAt)
+			c.mu.Unlock()
+			if remaining > 0 {
+				expiry.Reset(remaining)
+				continue
+			}
+			c.close(gatewayCloseUnauthorized, "token expired")
+			return
+		case ev, ok := <-events:
+			if !ok {
+				c.close(websocket.CloseTryAgainLater, "slow consumer")
+				return
+			}
+			if ev.channel == sessionsRevokedChannel && ev.id == c.userID {
+				c.close(gatewayCloseUnauthorized, "token revoked")
+				return
+			}
+			c.handleEvent(ctx, ev)
+		}
+	}
+}
+
+// handleEvent handles the events that concern this connection's user alone.
+// New chirps are matched against every connection at once by
+// dispatchGatewayChirps.
+func (c *gatewayConn) handleEvent(ctx context.Context, ev realtimeEvent) {
+	c.mu.Lock()
+	notifications := c.notifications
+	c.mu.Unlock()
+
+	if ev.channel != notificationsChannel || !notifications || ev.id != c.userID {
+		return
+	}
+	unread, err := c.cfg.db.CountUnreadNotifications(ctx, c.userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Could not count notifications in DB", "err", err)
+		return
+	}
+	c.enqueue(gatewayMessage{Type: "notifications", Topic: topicNotifications, Unread: &unread})
+}
+
+// gatewayHub tracks the gateway connections open on this server instance.
+type gatewayHub struct {
+	mu    sync.Mutex
+	conns map[*gatewayConn]struct{}
+}
+
+func newGatewayHub() *gatewayHub {
+	return &gatewayHub{conns: map[*gatewayConn]struct{}{}}
+}
+
+func (h *gatewayHub) add(c *gatewayConn) {
+	h.mu.Lock()
+	h.conns[c] = struct{}{}
+	h.mu.Unlock()
+}
+
+func (h *gatewayHub) remove(c *gatewayConn) {
+	h.mu.Lock()
+	delete(h.conns, c)
+	h.mu.Unlock()
+}
+
+// gatewaySubscription is what one connection was subscribed to when a chirp
+// came in.
+type gatewaySubscription struct {
+	conn    *gatewayConn
+	home    bool
+	threads []uuid.UUID
+}
+
+func (h *gatewayHub) subscriptions() []gatewaySubscription {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	subs := make([]gatewaySubscription, 0, len(h.conns))
+	for c := range h.conns {
+		c.mu.Lock()
+		sub := gatewaySubscription{conn: c, home: c.home}
+		for id := range c.threads {
+			sub.threads = append(sub.threads, id)
+		}
+		c.mu.Unlock()
+		if sub.home || len(sub.threads) > 0 {
+			subs = append(subs, sub)
+		}
+	}
+	return subs
+}
+
+// dispatchGatewayChirps sends each new chirp to the gateway connections
+// subscribed to a home timeline or thread it belongs in.
+func (cfg *apiConfig) dispatchGatewayChirps(ctx context.Context) {
+	events := cfg.realtime.subscribe()
+	defer func() { cfg.realtime.unsubscribe(events) }()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev, ok := <-events:
+			if !ok {
+				// Dropped by the hub for falling behind. The chirps missed
+				// meanwhile aren't sent; clients pick them up from the API.
+				slog.WarnContext(ctx, "Gateway fell behind on new chirps")
+				events = cfg.realtime.subscribe()
+				continue
+			}
+			if ev.channel == newChirpsChannel {
+				cfg.dispatchChirp(ctx, ev.id)
+			}
+		}
+	}
+}
+
+// dispatchChirp works out who a new chirp goes to with one query for its
+// ancestors and one for which connected users see it, and only then loads
+// it for each recipient.
+func (cfg *apiConfig) dispatchChirp(ctx context.Context, chirpID uuid.UUID) {
+	subs := cfg.gateway.subscriptions()
+	if len(subs) == 0 {
+		return
+	}
+
+	ancestors := map[uuid.UUID]bool{}
+	if slices.ContainsFunc(subs, func(sub gatewaySubscription) bool { return len(sub.threads) > 0 }) {
+		ids, err := cfg.db.GetChirpAncestorIDs(ctx, chirpID)
+		if err != nil {
+			slog.ErrorContext(ctx, "Could not retrieve ancestors from DB", "err", err)
+			return
+		}
+		for _, id := range ids {
+			ancestors[id] = true
+		}
+	}
+
+	// The threads each connection gets the chirp on, and the users who
+	// might get it at all.
+	inThreads := make([][]uuid.UUID, len(subs))
+	var candidates []uuid.UUID
+	seen := map[uuid.UUID]bool{}
+	for i, sub := range subs {
+		for _, threadID := range sub.threads {
+			if ancestors[threadID] {
+				inThreads[i] = append(inThreads[i], threadID)
+			}
+		}
+		if (sub.home || len(inThreads[i]) > 0) && !seen[sub.conn.userID] {
+			seen[sub.conn.userID] = true
+			candidates = append(candidates, sub.conn.userID)
+		}
+	}
+	if len(candidates) == 0 {
+		return
+	}
+
+	audience, err := cfg.db.GetChirpAudience(ctx, database.GetChirpAudienceParams{
+		UserIds: candidates,
+		ChirpID: chirpID,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Could not retrieve chirp audience from DB", "err", err)
+		return
+	}
+	if len(audience) == 0 {
+		return
+	}
+	inHome := make(map[uuid.UUID]bool, len(audience))
+	for _, row := range audience {
+		inHome[row.UserID] = row.InHome
+	}
+	dbChirp, err := cfg.db.GetChirpByID(ctx, database.GetChirpByIDParams{
+		ID:       chirpID,
+		ViewerID: audience[0].UserID,
+	})
+	if err != nil {
+		return
+	}
+
+	// Chirps are loaded as each recipient sees them, once per user however
+	// many connections they have open.
+	loaded := map[uuid.UUID]*Chirp{}
+	for i, sub := range subs {
+		userID := sub.conn.userID
+		home, visible := inHome[userID]
+		home = home && sub.home
+		if !visible || (!home && len(inThreads[i]) == 0) {
+			continue
+		}
+		chirp, ok := loaded[userID]
+		if !ok {
+			c, err := cfg.loadChirp(ctx, userID, dbChirp)
+			if err != nil {
+				slog.ErrorContext(ctx, "Could not load chirp from DB", "err", err)
+				continue
+			}
+			chirp = &c
+			loaded[userID] = chirp
+		}
+		if home {
+			sub.conn.enqueue(gatewayMessage{Type: "chirp", Topic: topicHome, Chirp: chirp})
+		}
+		for _, threadID := range inThreads[i] {
+			sub.conn.enqueue(gatewayMessage{Type: "chirp", Topic: topicThread, ChirpID: &threadID, Chirp: chirp})
+		}
+	}
+}
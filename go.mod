@@ -3,19 +3,80 @@ module github.com/pyculiar/chirpy
 go 1.24.2
 
 require (
+	github.com/gorilla/websocket v1.5.3
 	github.com/joho/godotenv v1.5.1
 	github.com/lib/pq v1.10.9
 	internal/auth v1.0.0
+	internal/blobstore v1.0.0
+	internal/content v1.0.0
 	internal/database v1.0.0
+	internal/images v1.0.0
+	internal/logging v1.0.0
+	internal/moderation v1.0.0
+	internal/pow v1.0.0
+	internal/ratelimit v1.0.0
+	internal/spam v1.0.0
+	internal/tracing v1.0.0
+	internal/visibility v1.0.0
 )
 
-require github.com/google/uuid v1.6.0
+require (
+	github.com/google/uuid v1.6.0
+	github.com/prometheus/client_golang v1.22.0
+	go.opentelemetry.io/otel v1.36.0
+	go.opentelemetry.io/otel/trace v1.36.0
+)
 
 require (
+	github.com/beorn7/perks v1.0.1 // indirect
+	github.com/cenkalti/backoff/v5 v5.0.2 // indirect
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	github.com/go-logr/logr v1.4.2 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
 	github.com/golang-jwt/jwt/v5 v5.2.2 // indirect
-	golang.org/x/crypto v0.37.0 // indirect
+	github.com/grpc-ecosystem/grpc-gateway/v2 v2.26.3 // indirect
+	github.com/munnerz/goautoneg v0.0.0-20191010083416-a7dc8b61c822 // indirect
+	github.com/prometheus/client_model v0.6.1 // indirect
+	github.com/prometheus/common v0.62.0 // indirect
+	github.com/prometheus/procfs v0.15.1 // indirect
+	github.com/rivo/uniseg v0.4.7 // indirect
+	go.opentelemetry.io/auto/sdk v1.1.0 // indirect
+	go.opentelemetry.io/otel/exporters/otlp/otlptrace v1.36.0 // indirect
+	go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp v1.36.0 // indirect
+	go.opentelemetry.io/otel/exporters/stdout/stdouttrace v1.36.0 // indirect
+	go.opentelemetry.io/otel/metric v1.36.0 // indirect
+	go.opentelemetry.io/otel/sdk v1.36.0 // indirect
+	go.opentelemetry.io/proto/otlp v1.6.0 // indirect
+	golang.org/x/crypto v0.38.0 // indirect
+	golang.org/x/net v0.40.0 // indirect
+	golang.org/x/sys v0.33.0 // indirect
+	golang.org/x/text v0.25.0 // indirect
+	google.golang.org/genproto/googleapis/api v0.0.0-20250519155744-55703ea1f237 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20250519155744-55703ea1f237 // indirect
+	google.golang.org/grpc v1.72.1 // indirect
+	google.golang.org/protobuf v1.36.6 // indirect
 )
 
 replace internal/database => ./internal/database
 
 replace internal/auth => ./internal/auth
+
+replace internal/blobstore => ./internal/blobstore
+
+replace internal/content => ./internal/content
+
+replace internal/moderation => ./internal/moderation
+
+replace internal/spam => ./internal/spam
+
+replace internal/pow => ./internal/pow
+
+replace internal/ratelimit => ./internal/ratelimit
+
+replace internal/logging => ./internal/logging
+
+replace internal/visibility => ./internal/visibility
+
+replace internal/tracing => ./internal/tracing
+
+replace internal/images => ./internal/images
@@ -0,0 +1,34 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// GetAPIKey returns the key from an "Authorization: ApiKey <key>" header.
+func GetAPIKey(headers http.Header) (string, error) {
+	scheme, key, ok := strings.Cut(headers.Get("Authorization"), " ")
+	if !ok || scheme != "ApiKey" || strings.TrimSpace(key) == "" {
+		return "", errors.New("no API key")
+	}
+	return strings.TrimSpace(key), nil
+}
+
+// MakeAPIKey returns a new random API key. Only its hash is stored.
+func MakeAPIKey() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(key), nil
+}
+
+// HashAPIKey returns the hash an API key is stored and looked up by.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
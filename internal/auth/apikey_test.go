@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestGetAPIKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		want    string
+		wantErr bool
+	}{
+		{"api key", "ApiKey abc123", "abc123", false},
+		{"bearer token", "Bearer abc123", "", true},
+		{"no key", "ApiKey ", "", true},
+		{"no scheme", "abc123", "", true},
+		{"missing", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headers := http.Header{}
+			if tt.header != "" {
+				headers.Set("Authorization", tt.header)
+			}
+			got, err := GetAPIKey(headers)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMakeAPIKey(t *testing.T) {
+	a, err := MakeAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := MakeAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a == b {
+		t.Error("MakeAPIKey returned the same key twice")
+	}
+	if HashAPIKey(a) != HashAPIKey(a) {
+		t.Error("HashAPIKey isn't deterministic")
+	}
+	if HashAPIKey(a) == HashAPIKey(b) || HashAPIKey(a) == a {
+		t.Error("HashAPIKey doesn't distinguish or hide keys")
+	}
+}
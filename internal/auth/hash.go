@@ -2,13 +2,13 @@ package auth
 
 import (
 	"golang.org/x/crypto/bcrypt"
-	"log"
+	"log/slog"
 )
 
 func HashPassword(password string) (string, error) {
 	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
-		log.Printf("Something went wrong: %v", err)
+		slog.Error("Could not hash password", "err", err)
 		return "", err
 	}
 	return string(hash[:]), nil
@@ -7,7 +7,7 @@ import (
 	"fmt"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
-	"log"
+	"log/slog"
 	"net/http"
 	"strings"
 	"time"
@@ -29,6 +29,21 @@ func MakeJWT(userID uuid.UUID, tokenSecret string) (string, error) {
 }
 
 func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
+	claims, err := ValidateAccessToken(tokenString, tokenSecret)
+	return claims.UserID, err
+}
+
+// AccessClaims is what a valid access token says about its session.
+type AccessClaims struct {
+	UserID    uuid.UUID
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+// ValidateAccessToken is ValidateJWT that also returns when the token was
+// issued and when it expires, for long-lived connections that must end when
+// their token does or when the user's sessions are revoked.
+func ValidateAccessToken(tokenString, tokenSecret string) (AccessClaims, error) {
 	claimsStruct := jwt.RegisteredClaims{}
 	token, err := jwt.ParseWithClaims(
 		tokenString,
@@ -36,42 +51,51 @@ func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
 		func(token *jwt.Token) (interface{}, error) { return []byte(tokenSecret), nil },
 	)
 	if err != nil {
-		return uuid.Nil, err
+		return AccessClaims{}, err
 	}
 
 	userIDString, err := token.Claims.GetSubject()
 	if err != nil {
-		return uuid.Nil, err
+		return AccessClaims{}, err
 	}
 
 	issuer, err := token.Claims.GetIssuer()
 	if err != nil {
-		return uuid.Nil, err
+		return AccessClaims{}, err
 	}
 	if issuer != string(TokenTypeAccess) {
-		return uuid.Nil, errors.New("invalid issuer")
+		return AccessClaims{}, errors.New("invalid issuer")
+	}
+
+	issuedAt, err := token.Claims.GetIssuedAt()
+	if err != nil || issuedAt == nil {
+		return AccessClaims{}, errors.New("missing issue time")
+	}
+	expiresAt, err := token.Claims.GetExpirationTime()
+	if err != nil || expiresAt == nil {
+		return AccessClaims{}, errors.New("missing expiration")
 	}
 
 	id, err := uuid.Parse(userIDString)
 	if err != nil {
-		return uuid.Nil, fmt.Errorf("invalid user ID: %w", err)
+		return AccessClaims{}, fmt.Errorf("invalid user ID: %w", err)
 	}
-	return id, nil
+	return AccessClaims{UserID: id, IssuedAt: issuedAt.Time, ExpiresAt: expiresAt.Time}, nil
 }
 
 func GetBearerToken(headers http.Header) (string, error) {
-	if val, ok := headers["Authorization"]; ok {
-		splits := strings.Split(val[0], " ")
-		return splits[1], nil
+	scheme, token, ok := strings.Cut(headers.Get("Authorization"), " ")
+	if !ok || scheme != "Bearer" || strings.TrimSpace(token) == "" {
+		return "", errors.New("no authorization")
 	}
-	return "", errors.New("no authorization")
+	return strings.TrimSpace(token), nil
 }
 
 func MakeRefreshToken() (string, error) {
 	randomData := make([]byte, 32)
 	_, err := rand.Read(randomData)
 	if err != nil {
-		log.Printf("Failed to create refresh token: %v", err)
+		slog.Error("Could not create refresh token", "err", err)
 		return "", err
 	}
 	refreshToken := hex.EncodeToString(randomData)
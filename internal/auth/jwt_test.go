@@ -1,8 +1,11 @@
 package auth
 
 import (
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"net/http"
 	"testing"
+	"time"
 )
 
 func TestValidateJWT(t *testing.T) {
@@ -52,3 +55,71 @@ func TestValidateJWT(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateAccessToken(t *testing.T) {
+	userID := uuid.New()
+	before := time.Now()
+	token, err := MakeJWT(userID, "secret")
+	if err != nil {
+		t.Fatalf("MakeJWT() error = %v", err)
+	}
+
+	claims, err := ValidateAccessToken(token, "secret")
+	if err != nil {
+		t.Fatalf("ValidateAccessToken() error = %v", err)
+	}
+	if claims.UserID != userID {
+		t.Errorf("ValidateAccessToken() UserID = %v, want %v", claims.UserID, userID)
+	}
+	if claims.IssuedAt.Before(before.Add(-time.Second)) || claims.IssuedAt.After(time.Now()) {
+		t.Errorf("ValidateAccessToken() IssuedAt = %v, want about now", claims.IssuedAt)
+	}
+	if claims.ExpiresAt.Before(before.Add(59*time.Minute)) || claims.ExpiresAt.After(before.Add(61*time.Minute)) {
+		t.Errorf("ValidateAccessToken() ExpiresAt = %v, want about an hour from now", claims.ExpiresAt)
+	}
+}
+
+func TestValidateAccessTokenRequiresIssueTime(t *testing.T) {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		Issuer:    string(TokenTypeAccess),
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		Subject:   uuid.New().String(),
+	}).SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatalf("SignedString() error = %v", err)
+	}
+	if _, err := ValidateAccessToken(token, "secret"); err == nil {
+		t.Error("ValidateAccessToken() accepted a token without iat")
+	}
+}
+
+func TestGetBearerToken(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		want    string
+		wantErr bool
+	}{
+		{"bearer token", "Bearer abc123", "abc123", false},
+		{"api key", "ApiKey abc123", "", true},
+		{"scheme only", "Bearer", "", true},
+		{"no token", "Bearer ", "", true},
+		{"no scheme", "abc123", "", true},
+		{"missing", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headers := http.Header{}
+			if tt.header != "" {
+				headers.Set("Authorization", tt.header)
+			}
+			got, err := GetBearerToken(headers)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
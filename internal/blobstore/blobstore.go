@@ -0,0 +1,44 @@
+// Package blobstore stores uploaded files behind a small interface so the
+// server can keep them on local disk or in an S3-compatible bucket.
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+var ErrNotFound = errors.New("blob not found")
+
+// Info describes a stored blob.
+type Info struct {
+	ContentType  string
+	Size         int64
+	LastModified time.Time
+}
+
+type BlobStore interface {
+	// Put stores size bytes from body under key, replacing any existing blob.
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	// Get opens the blob stored under key. The caller must close it. It
+	// returns ErrNotFound when there is no such blob.
+	Get(ctx context.Context, key string) (io.ReadCloser, Info, error)
+	// Delete removes the blob under key. Deleting a missing blob is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// validKey reports whether key is a relative slash-separated path that stays
+// inside the store.
+func validKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return false
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,3 @@
+module internal/blobstore
+
+go 1.24.2
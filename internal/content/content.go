@@ -0,0 +1,107 @@
+// Package content prepares user-written text for storage: it normalizes the
+// text and measures its length the way people count it, so limits apply
+// fairly across scripts and emoji.
+package content
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/rivo/uniseg"
+	"golang.org/x/text/unicode/norm"
+)
+
+// URLLength is what every URL counts for, so shortened and full links cost
+// the same. Only the first MaxURLLength bytes of a URL are covered; the rest
+// counts character by character, so a URL can't smuggle in unlimited text.
+const (
+	URLLength    = 23
+	MaxURLLength = 512
+)
+
+// MaxBytesPerCharacter bounds the size of text Check accepts before
+// measuring it: limit characters may take at most this many bytes each.
+// It leaves room for the longest emoji sequences, but not for piles of
+// combining marks or a body too large to be worth normalizing.
+const MaxBytesPerCharacter = 32
+
+var urlPattern = regexp.MustCompile(`https?://[^\s]+`)
+
+// LengthError reports text over its length limit.
+type LengthError struct {
+	Length int
+	Limit  int
+}
+
+func (e *LengthError) Error() string {
+	return fmt.Sprintf("text is %d characters, over the limit of %d", e.Length, e.Limit)
+}
+
+// Normalize puts text in NFC form, turns CRLF line endings into LF, removes
+// control characters other than newlines and tabs, removes bidirectional
+// embeddings, overrides and isolates, and trims surrounding whitespace. The
+// bidi controls are dropped because they can make text display differently
+// from how it reads, for example to disguise a link.
+func Normalize(text string) string {
+	text = norm.NFC.String(strings.ReplaceAll(text, "\r\n", "\n"))
+	text = strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\t' {
+			return r
+		}
+		if unicode.IsControl(r) || isBidiControl(r) {
+			return -1
+		}
+		return r
+	}, text)
+	return strings.TrimSpace(text)
+}
+
+// isBidiControl reports whether r is an embedding, override or isolate.
+// The plain direction marks (LRM, RLM and ALM) are harmless and kept.
+func isBidiControl(r rune) bool {
+	return (r >= '\u202a' && r <= '\u202e') || (r >= '\u2066' && r <= '\u2069')
+}
+
+// Length counts user-perceived characters (grapheme clusters), so an emoji
+// made of several code points counts once. Each URL counts as URLLength,
+// plus whatever it runs to past MaxURLLength.
+func Length(text string) int {
+	length := 0
+	last := 0
+	for _, loc := range urlPattern.FindAllStringIndex(text, -1) {
+		end := loc[0] + len(trimURL(text[loc[0]:loc[1]]))
+		length += uniseg.GraphemeClusterCount(text[last:loc[0]]) + URLLength
+		last = end
+		if end-loc[0] > MaxURLLength {
+			last = loc[0] + MaxURLLength
+			for !utf8.RuneStart(text[last]) {
+				last--
+			}
+		}
+	}
+	return length + uniseg.GraphemeClusterCount(text[last:])
+}
+
+// trimURL drops punctuation that more likely ends the sentence than the
+// URL.
+func trimURL(url string) string {
+	return strings.TrimRight(url, ".,:;!?)]}'\"")
+}
+
+// Check normalizes text and checks it against limit. Text over the limit is
+// reported as a *LengthError. Text over MaxBytesPerCharacter bytes for each
+// character allowed is refused without being measured, and its length is
+// reported in code points.
+func Check(text string, limit int) (string, error) {
+	if len(text) > limit*MaxBytesPerCharacter {
+		return "", &LengthError{Length: utf8.RuneCountInString(text), Limit: limit}
+	}
+	text = Normalize(text)
+	if length := Length(text); length > limit {
+		return "", &LengthError{Length: length, Limit: limit}
+	}
+	return text, nil
+}
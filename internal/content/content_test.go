@@ -0,0 +1,101 @@
+package content
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"plain", "hello world", "hello world"},
+		{"surrounding whitespace", "  hello\n\n", "hello"},
+		{"crlf", "one\r\ntwo", "one\ntwo"},
+		{"nfc", "e\u0301", "\u00e9"},
+		{"control characters", "bell\a and null\x00", "bell and null"},
+		{"bidi override", "abc\u202egnp.exe", "abcgnp.exe"},
+		{"bidi isolate", "\u2067hello\u2069", "hello"},
+		{"direction marks kept", "a\u200fb", "a\u200fb"},
+		{"zero width joiner kept", "\U0001F469\u200d\U0001F4BB", "\U0001F469\u200d\U0001F4BB"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Normalize(tt.text); got != tt.want {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLength(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want int
+	}{
+		{"ascii", "hello", 5},
+		{"japanese", "こんにちは", 5},
+		{"emoji sequence", "\U0001F469\u200d\U0001F4BB", 1},
+		{"flag", "\U0001F1EF\U0001F1F5", 1},
+		{"skin tone", "\U0001F44D\U0001F3FD", 1},
+		{"combining mark", "e\u0301", 1},
+		{"short url", "see http://a.co", 4 + URLLength},
+		{"long url", "see https://example.com/" + strings.Repeat("a", 100), 4 + URLLength},
+		{"url before punctuation", "(https://example.com).", 1 + URLLength + 2},
+		{"two urls", "https://a.co https://b.co", 2*URLLength + 1},
+		{"url at the cap", "https://example.com/" + strings.Repeat("a", MaxURLLength-20), URLLength},
+		{"url over the cap", "https://example.com/" + strings.Repeat("a", MaxURLLength), URLLength + 20},
+		{"cap inside a character", "https://example.co/" + strings.Repeat("é", 256), URLLength + 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Length(tt.text); got != tt.want {
+				t.Errorf("Length(%q) = %d, want %d", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	// 140 emoji would be rejected by a byte count at 35.
+	text, err := Check(strings.Repeat("\U0001F600", 140), 140)
+	if err != nil {
+		t.Fatalf("Check of 140 emoji: %v", err)
+	}
+	if Length(text) != 140 {
+		t.Errorf("Length after Check = %d", Length(text))
+	}
+
+	_, err = Check(strings.Repeat("あ", 141), 140)
+	var tooLong *LengthError
+	if !errors.As(err, &tooLong) {
+		t.Fatalf("Check of 141 characters = %v, want a LengthError", err)
+	}
+	if tooLong.Length != 141 || tooLong.Limit != 140 {
+		t.Errorf("LengthError = %+v", tooLong)
+	}
+}
+
+func TestCheckByteCeiling(t *testing.T) {
+	// One character, but far too many bytes to store.
+	zalgo := "a" + strings.Repeat("\u0301", 140*MaxBytesPerCharacter)
+	_, err := Check(zalgo, 140)
+	var tooLong *LengthError
+	if !errors.As(err, &tooLong) {
+		t.Fatalf("Check of a huge grapheme = %v, want a LengthError", err)
+	}
+	if tooLong.Length <= tooLong.Limit {
+		t.Errorf("LengthError = %+v, want a length over the limit", tooLong)
+	}
+
+	// The same number of bytes spread over URLs is refused too.
+	url := "https://example.com/" + strings.Repeat("a", MaxURLLength-20) + " "
+	_, err = Check(strings.Repeat(url, 140*MaxBytesPerCharacter/len(url)+1), 140)
+	if !errors.As(err, &tooLong) {
+		t.Fatalf("Check of many long URLs = %v, want a LengthError", err)
+	}
+}
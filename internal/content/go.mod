@@ -0,0 +1,8 @@
+module internal/content
+
+go 1.24.2
+
+require (
+	github.com/rivo/uniseg v0.4.7
+	golang.org/x/text v0.24.0
+)
@@ -0,0 +1,70 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestGetChirpsAfterFilters(t *testing.T) {
+	conn := openTestDB(t)
+	q := New(conn)
+	alice := createTestUser(t, conn)
+	bob := createTestUser(t, conn)
+
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	posts := []struct {
+		author uuid.UUID
+		body   string
+	}{
+		{alice, "morning #Coffee."},
+		{alice, "no tags here"},
+		{bob, "(#coffee) is great"},
+		{bob, "#coffeebreak"},
+		{bob, "more #coffee!"},
+	}
+	ids := make([]uuid.UUID, len(posts))
+	for i, post := range posts {
+		ids[i] = createTestChirp(t, conn, post.author, "public")
+		execTest(t, conn, "UPDATE chirps SET body = $1, created_at = $2 WHERE id = $3",
+			post.body, start.Add(time.Duration(i)*time.Minute), ids[i])
+	}
+
+	tests := []struct {
+		name     string
+		authorID uuid.NullUUID
+		hashtag  sql.NullString
+		want     []uuid.UUID
+	}{
+		{"everything", uuid.NullUUID{}, sql.NullString{}, ids},
+		{"author", uuid.NullUUID{UUID: alice, Valid: true}, sql.NullString{}, ids[:2]},
+		{"hashtag", uuid.NullUUID{}, sql.NullString{String: "coffee", Valid: true}, []uuid.UUID{ids[0], ids[4]}},
+		{"author and hashtag", uuid.NullUUID{UUID: bob, Valid: true}, sql.NullString{String: "COFFEE", Valid: true}, ids[4:]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chirps, err := q.GetChirpsAfter(context.Background(), GetChirpsAfterParams{
+				CursorCreatedAt: start.Add(-time.Minute),
+				CursorID:        uuid.Nil,
+				AuthorID:        tt.authorID,
+				Hashtag:         tt.hashtag,
+				ViewerID:        uuid.Nil,
+				PageSize:        10,
+			})
+			if err != nil {
+				t.Fatalf("GetChirpsAfter() error = %v", err)
+			}
+			if len(chirps) != len(tt.want) {
+				t.Fatalf("GetChirpsAfter() returned %d chirps, want %d", len(chirps), len(tt.want))
+			}
+			for i, chirp := range chirps {
+				if chirp.ID != tt.want[i] {
+					t.Errorf("chirp %d = %v, want %v", i, chirp.ID, tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -11,12 +11,223 @@ import (
 	"github.com/google/uuid"
 )
 
+type ApiKey struct {
+	ID        uuid.UUID
+	Name      string
+	KeyHash   string
+	CreatedAt time.Time
+	CreatedBy uuid.UUID
+	RevokedAt sql.NullTime
+}
+
+type Block struct {
+	BlockerID uuid.UUID
+	BlockedID uuid.UUID
+	CreatedAt time.Time
+}
+
+type Bookmark struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+}
+
 type Chirp struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Body        string
+	UserID      uuid.UUID
+	InReplyToID uuid.NullUUID
+	RechirpOfID uuid.NullUUID
+	QuoteOfID   uuid.NullUUID
+	Visibility  string
+}
+
+type ChirpFlag struct {
+	ChirpID   uuid.UUID
+	Words     []string
+	CreatedAt time.Time
+}
+
+type ChirpMention struct {
+	ChirpID uuid.UUID
+	UserID  uuid.UUID
+}
+
+type Conversation struct {
 	ID        uuid.UUID
 	CreatedAt time.Time
 	UpdatedAt time.Time
-	Body      string
+	DirectKey sql.NullString
+}
+
+type ConversationMember struct {
+	ConversationID uuid.UUID
+	UserID         uuid.UUID
+	JoinedAt       time.Time
+	LastReadAt     sql.NullTime
+}
+
+type DirectMessage struct {
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	ConversationID uuid.UUID
+	SenderID       uuid.UUID
+	Body           string
+}
+
+type Draft struct {
+	ID                  uuid.UUID
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+	UserID              uuid.UUID
+	Body                string
+	InReplyToID         uuid.NullUUID
+	QuoteOfID           uuid.NullUUID
+	Mentions            []uuid.UUID
+	MediaIds            []uuid.UUID
+	PublishAt           sql.NullTime
+	Failure             sql.NullString
+	PollOptions         []string
+	PollDurationMinutes sql.NullInt32
+	Visibility          string
+}
+
+type Follow struct {
+	FollowerID uuid.UUID
+	FolloweeID uuid.UUID
+	CreatedAt  time.Time
+}
+
+type FollowCount struct {
+	UserID         uuid.UUID
+	FollowerCount  int32
+	FollowingCount int32
+}
+
+type HeldChirp struct {
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+	Score     float64
+}
+
+type HiddenChirp struct {
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+}
+
+type Invite struct {
+	ID        uuid.UUID
+	Code      string
+	CreatedAt time.Time
+	CreatedBy uuid.UUID
+	MaxUses   int32
+	Uses      int32
+	ExpiresAt sql.NullTime
+	RevokedAt sql.NullTime
+}
+
+type Like struct {
+	ChirpID   uuid.UUID
+	UserID    uuid.UUID
+	CreatedAt time.Time
+}
+
+type LikeCountShard struct {
+	ChirpID uuid.UUID
+	Shard   int16
+	Count   int32
+}
+
+type Medium struct {
+	ID           uuid.UUID
+	CreatedAt    time.Time
+	UserID       uuid.UUID
+	ChirpID      uuid.NullUUID
+	Position     int16
+	ContentType  string
+	Width        int32
+	Height       int32
+	SizeBytes    int64
+	StorageKey   string
+	ThumbnailKey string
+}
+
+type ModerationLog struct {
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	ModeratorID    uuid.UUID
+	Action         string
+	ReportID       uuid.NullUUID
+	UserID         uuid.NullUUID
+	ChirpID        uuid.NullUUID
+	SuspendedUntil sql.NullTime
+	Note           string
+}
+
+type ModerationWord struct {
+	Word      string
+	Action    string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type Mute struct {
+	MuterID   uuid.UUID
+	MutedID   uuid.UUID
+	CreatedAt time.Time
+}
+
+type Notification struct {
+	ID         uuid.UUID
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	UserID     uuid.UUID
+	Kind       string
+	ActorID    uuid.UUID
+	ChirpID    uuid.NullUUID
+	GroupKey   sql.NullString
+	ActorCount int32
+	ReadAt     sql.NullTime
+}
+
+type NotificationActor struct {
+	NotificationID uuid.UUID
+	ActorID        uuid.UUID
+}
+
+type PinnedChirp struct {
 	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	Slot      int16
+	CreatedAt time.Time
+}
+
+type Poll struct {
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+	ClosesAt  time.Time
+}
+
+type PollOption struct {
+	ChirpID  uuid.UUID
+	Position int16
+	Label    string
+}
+
+type PollVote struct {
+	ChirpID   uuid.UUID
+	UserID    uuid.UUID
+	Position  int16
+	CreatedAt time.Time
+}
+
+type RateLimitBucket struct {
+	Key       string
+	Tokens    float64
+	UpdatedAt time.Time
+	FullAt    time.Time
 }
 
 type RefreshToken struct {
@@ -28,10 +239,59 @@ type RefreshToken struct {
 	UserID    uuid.UUID
 }
 
+type Report struct {
+	ID         uuid.UUID
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	ReporterID uuid.UUID
+	UserID     uuid.UUID
+	ChirpID    uuid.NullUUID
+	Reason     string
+	Details    string
+	Status     string
+	ClaimedBy  uuid.NullUUID
+	ClaimedAt  sql.NullTime
+	ClosedAt   sql.NullTime
+}
+
+type SessionRevocation struct {
+	UserID    uuid.UUID
+	RevokedAt time.Time
+}
+
+type ShadowBan struct {
+	UserID      uuid.UUID
+	CreatedAt   time.Time
+	ModeratorID uuid.NullUUID
+}
+
+type SpamLabel struct {
+	ChirpID     uuid.UUID
+	CreatedAt   time.Time
+	Body        string
+	Spam        bool
+	ModeratorID uuid.NullUUID
+}
+
+type SpentChallenge struct {
+	Challenge string
+	ExpiresAt time.Time
+}
+
+type TimelineEntry struct {
+	UserID         uuid.UUID
+	ChirpID        uuid.UUID
+	ChirpCreatedAt time.Time
+}
+
 type User struct {
 	ID             uuid.UUID
 	CreatedAt      time.Time
 	UpdatedAt      time.Time
 	Email          string
 	HashedPassword string
+	Role           string
+	SuspendedUntil sql.NullTime
+	BannedAt       sql.NullTime
+	InvitedBy      uuid.NullUUID
 }
@@ -0,0 +1,49 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestCreateNotificationCountsDistinctActors(t *testing.T) {
+	conn := openTestDB(t)
+	q := New(conn)
+	ctx := context.Background()
+	author := createTestUser(t, conn)
+	fan := createTestUser(t, conn)
+	other := createTestUser(t, conn)
+	chirp := createTestChirp(t, conn, author, "public")
+
+	like := func(actorID uuid.UUID) {
+		err := q.CreateNotification(ctx, CreateNotificationParams{
+			UserID:   author,
+			Kind:     "like",
+			ActorID:  actorID,
+			ChirpID:  uuid.NullUUID{UUID: chirp, Valid: true},
+			GroupKey: sql.NullString{String: "like:" + chirp.String(), Valid: true},
+		})
+		if err != nil {
+			t.Fatalf("CreateNotification() error = %v", err)
+		}
+	}
+	like(fan)
+	like(fan)
+	like(other)
+	like(fan)
+
+	var count int32
+	var actorID uuid.UUID
+	err := conn.QueryRowContext(ctx, "SELECT actor_count, actor_id FROM notifications WHERE user_id = $1", author).Scan(&count, &actorID)
+	if err != nil {
+		t.Fatalf("Could not read notification: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("actor_count = %d, want 2", count)
+	}
+	if actorID != other {
+		t.Errorf("actor_id = %v, want the last new actor %v", actorID, other)
+	}
+}
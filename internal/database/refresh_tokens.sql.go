@@ -7,8 +7,10 @@ package database
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createRefreshToken = `-- name: CreateRefreshToken :one
@@ -43,6 +45,18 @@ func (q *Queries) CreateRefreshToken(ctx context.Context, arg CreateRefreshToken
 	return i, err
 }
 
+const getSessionsRevokedAt = `-- name: GetSessionsRevokedAt :one
+SELECT revoked_at FROM session_revocations
+WHERE user_id = $1
+`
+
+func (q *Queries) GetSessionsRevokedAt(ctx context.Context, userID uuid.UUID) (time.Time, error) {
+	row := q.db.QueryRowContext(ctx, getSessionsRevokedAt, userID)
+	var revoked_at time.Time
+	err := row.Scan(&revoked_at)
+	return revoked_at, err
+}
+
 const getUserFromRefreshToken = `-- name: GetUserFromRefreshToken :one
 SELECT token, created_at, updated_at, expires_at, revoked_at, user_id FROM refresh_tokens
 WHERE token = $1
@@ -61,3 +75,53 @@ func (q *Queries) GetUserFromRefreshToken(ctx context.Context, token string) (Re
 	)
 	return i, err
 }
+
+const notifySessionRevoked = `-- name: NotifySessionRevoked :exec
+SELECT pg_notify('sessions_revoked', $1::text)
+`
+
+func (q *Queries) NotifySessionRevoked(ctx context.Context, userID string) error {
+	_, err := q.db.ExecContext(ctx, notifySessionRevoked, userID)
+	return err
+}
+
+const revokeRefreshToken = `-- name: RevokeRefreshToken :one
+UPDATE refresh_tokens SET revoked_at = NOW(), updated_at = NOW()
+WHERE token = $1
+RETURNING token, created_at, updated_at, expires_at, revoked_at, user_id
+`
+
+func (q *Queries) RevokeRefreshToken(ctx context.Context, token string) (RefreshToken, error) {
+	row := q.db.QueryRowContext(ctx, revokeRefreshToken, token)
+	var i RefreshToken
+	err := row.Scan(
+		&i.Token,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.UserID,
+	)
+	return i, err
+}
+
+const revokeRefreshTokensByUsers = `-- name: RevokeRefreshTokensByUsers :exec
+UPDATE refresh_tokens SET revoked_at = NOW(), updated_at = NOW()
+WHERE user_id = ANY($1::uuid[]) AND revoked_at IS NULL
+`
+
+func (q *Queries) RevokeRefreshTokensByUsers(ctx context.Context, userIds []uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, revokeRefreshTokensByUsers, pq.Array(userIds))
+	return err
+}
+
+const revokeSessions = `-- name: RevokeSessions :exec
+INSERT INTO session_revocations (user_id, revoked_at)
+VALUES ($1, NOW())
+ON CONFLICT (user_id) DO UPDATE SET revoked_at = excluded.revoked_at
+`
+
+func (q *Queries) RevokeSessions(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, revokeSessions, userID)
+	return err
+}
@@ -0,0 +1,94 @@
+package database
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+)
+
+// openTestDB migrates a throwaway schema in the database at TEST_DB_URL and
+// returns a connection that uses it. Tests that need it are skipped when
+// TEST_DB_URL isn't set.
+func openTestDB(t *testing.T) *sql.Conn {
+	t.Helper()
+	dbURL := os.Getenv("TEST_DB_URL")
+	if dbURL == "" {
+		t.Skip("TEST_DB_URL not set")
+	}
+	ctx := context.Background()
+	db, err := sql.Open("postgres", dbURL)
+	if err != nil {
+		t.Fatalf("sql.Open() error = %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatalf("db.Conn() error = %v", err)
+	}
+
+	b := make([]byte, 8)
+	rand.Read(b)
+	schema := "chirpy_test_" + hex.EncodeToString(b)
+	if _, err := conn.ExecContext(ctx, "CREATE SCHEMA "+schema+"; SET search_path TO "+schema); err != nil {
+		t.Fatalf("Could not create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		conn.ExecContext(ctx, "DROP SCHEMA "+schema+" CASCADE")
+		conn.Close()
+	})
+
+	files, err := filepath.Glob("../../sql/schema/*.sql")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("Could not find migrations: %v", err)
+	}
+	for _, file := range files {
+		dat, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("Could not read %s: %v", file, err)
+		}
+		up, _, _ := strings.Cut(string(dat), "-- +goose Down")
+		if _, err := conn.ExecContext(ctx, up); err != nil {
+			t.Fatalf("Could not apply %s: %v", filepath.Base(file), err)
+		}
+	}
+	return conn
+}
+
+func createTestUser(t *testing.T, conn *sql.Conn) uuid.UUID {
+	t.Helper()
+	id := uuid.New()
+	_, err := conn.ExecContext(context.Background(), `
+		INSERT INTO users (id, created_at, updated_at, email)
+		VALUES ($1, NOW(), NOW(), $2)`, id, id.String()+"@example.com")
+	if err != nil {
+		t.Fatalf("Could not create user: %v", err)
+	}
+	return id
+}
+
+func createTestChirp(t *testing.T, conn *sql.Conn, userID uuid.UUID, visibility string) uuid.UUID {
+	t.Helper()
+	id := uuid.New()
+	_, err := conn.ExecContext(context.Background(), `
+		INSERT INTO chirps (id, created_at, updated_at, body, user_id, visibility)
+		VALUES ($1, NOW(), NOW(), 'hello', $2, $3)`, id, userID, visibility)
+	if err != nil {
+		t.Fatalf("Could not create chirp: %v", err)
+	}
+	return id
+}
+
+func execTest(t *testing.T, conn *sql.Conn, query string, args ...interface{}) {
+	t.Helper()
+	if _, err := conn.ExecContext(context.Background(), query, args...); err != nil {
+		t.Fatalf("Could not run %q: %v", query, err)
+	}
+}
@@ -0,0 +1,106 @@
+package database
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestGetReplyTree(t *testing.T) {
+	conn := openTestDB(t)
+	q := New(conn)
+	author := createTestUser(t, conn)
+	reply := func(parent uuid.UUID) uuid.UUID {
+		id := createTestChirp(t, conn, author, "public")
+		execTest(t, conn, "UPDATE chirps SET in_reply_to_id = $1 WHERE id = $2", parent, id)
+		return id
+	}
+
+	root := createTestChirp(t, conn, author, "public")
+	busy := reply(root)
+	quiet := reply(root)
+	for range 3 {
+		reply(busy)
+	}
+	deep := reply(quiet)
+	deeper := reply(deep)
+	// Posted last, but nearer the top than everything but busy and quiet.
+	late := reply(root)
+
+	get := func(perParent, maxDepth, maxReplies int32) map[uuid.UUID]GetReplyTreeRow {
+		rows, err := q.GetReplyTree(context.Background(), GetReplyTreeParams{
+			ChirpID:          root,
+			ViewerID:         author,
+			CursorCreatedAt:  time.Time{},
+			CursorID:         uuid.Nil,
+			PageSize:         10,
+			RepliesPerParent: perParent,
+			MaxDepth:         maxDepth,
+			MaxReplies:       maxReplies,
+		})
+		if err != nil {
+			t.Fatalf("GetReplyTree() error = %v", err)
+		}
+		byID := map[uuid.UUID]GetReplyTreeRow{}
+		for _, row := range rows {
+			byID[row.Chirp.ID] = row
+		}
+		return byID
+	}
+
+	all := get(10, 10, 100)
+	if len(all) != 8 {
+		t.Fatalf("GetReplyTree() returned %d replies, want 8", len(all))
+	}
+	for id, row := range all {
+		if row.MoreReplies {
+			t.Errorf("reply %v has MoreReplies with the whole tree shown", id)
+		}
+	}
+	if all[deeper].Depth != 3 {
+		t.Errorf("deepest reply Depth = %d, want 3", all[deeper].Depth)
+	}
+
+	perParent := get(2, 10, 100)
+	if len(perParent) != 7 || !perParent[busy].MoreReplies || perParent[quiet].MoreReplies {
+		t.Errorf("with 2 replies per parent got %d replies, busy more = %v, quiet more = %v",
+			len(perParent), perParent[busy].MoreReplies, perParent[quiet].MoreReplies)
+	}
+
+	shallow := get(10, 2, 100)
+	if _, ok := shallow[deeper]; ok || !shallow[deep].MoreReplies {
+		t.Errorf("at depth 2 got deeper = %v, deep more = %v", ok, shallow[deep].MoreReplies)
+	}
+
+	capped := get(10, 10, 3)
+	_, lateShown := capped[late]
+	if len(capped) != 3 || !lateShown || !capped[busy].MoreReplies || !capped[quiet].MoreReplies {
+		t.Errorf("with 3 replies in all got %d replies, want just the top level marked as having more", len(capped))
+	}
+}
+
+func TestGetChirpAncestorIDs(t *testing.T) {
+	conn := openTestDB(t)
+	q := New(conn)
+	author := createTestUser(t, conn)
+	root := createTestChirp(t, conn, author, "public")
+	parent := createTestChirp(t, conn, author, "followers")
+	reply := createTestChirp(t, conn, author, "public")
+	execTest(t, conn, "UPDATE chirps SET in_reply_to_id = $1 WHERE id = $2", root, parent)
+	execTest(t, conn, "UPDATE chirps SET in_reply_to_id = $1 WHERE id = $2", parent, reply)
+
+	ids, err := q.GetChirpAncestorIDs(context.Background(), reply)
+	if err != nil {
+		t.Fatalf("GetChirpAncestorIDs() error = %v", err)
+	}
+	if len(ids) != 2 || !slices.Contains(ids, root) || !slices.Contains(ids, parent) {
+		t.Errorf("GetChirpAncestorIDs() = %v, want the parent and root", ids)
+	}
+	ids, err = q.GetChirpAncestorIDs(context.Background(), root)
+	if err != nil || len(ids) != 0 {
+		t.Errorf("GetChirpAncestorIDs(root) = %v, %v, want none", ids, err)
+	}
+}
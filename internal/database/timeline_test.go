@@ -0,0 +1,142 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TestGetHomeTimelineFilters checks that chirps the viewer won't see don't
+// use up a page, even when there are more of them than fit in one.
+func TestGetHomeTimelineFilters(t *testing.T) {
+	const pageSize = 3
+	hides := []struct {
+		name string
+		// apply hides the author, or their chirps, from the viewer.
+		apply func(t *testing.T, conn *sql.Conn, viewer, author uuid.UUID, chirps []uuid.UUID)
+	}{
+		{"muted", func(t *testing.T, conn *sql.Conn, viewer, author uuid.UUID, _ []uuid.UUID) {
+			execTest(t, conn, "INSERT INTO mutes (muter_id, muted_id, created_at) VALUES ($1, $2, NOW())", viewer, author)
+		}},
+		{"shadow banned", func(t *testing.T, conn *sql.Conn, _, author uuid.UUID, _ []uuid.UUID) {
+			execTest(t, conn, "INSERT INTO shadow_bans (user_id, created_at) VALUES ($1, NOW())", author)
+		}},
+		{"held", func(t *testing.T, conn *sql.Conn, _, _ uuid.UUID, chirps []uuid.UUID) {
+			for _, id := range chirps {
+				execTest(t, conn, "INSERT INTO held_chirps (chirp_id, created_at, score) VALUES ($1, NOW(), 1)", id)
+			}
+		}},
+	}
+	for _, hide := range hides {
+		// Chirps are either pushed into timeline_entries or, for popular
+		// authors, pulled when the timeline is read.
+		for _, fanOut := range []bool{true, false} {
+			name := hide.name + " pulled"
+			if fanOut {
+				name = hide.name + " fanned out"
+			}
+			t.Run(name, func(t *testing.T) {
+				conn := openTestDB(t)
+				q := New(conn)
+				ctx := context.Background()
+				viewer := createTestUser(t, conn)
+				friend := createTestUser(t, conn)
+				hidden := createTestUser(t, conn)
+				threshold := int32(1000)
+				if !fanOut {
+					threshold = 1
+				}
+				for _, followee := range []uuid.UUID{friend, hidden} {
+					execTest(t, conn, "INSERT INTO follows (follower_id, followee_id, created_at) VALUES ($1, $2, NOW())", viewer, followee)
+					if !fanOut {
+						execTest(t, conn, "INSERT INTO follow_counts (user_id, follower_count) VALUES ($1, 1)", followee)
+					}
+				}
+
+				start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+				post := func(author uuid.UUID, i int) uuid.UUID {
+					id := createTestChirp(t, conn, author, "public")
+					execTest(t, conn, "UPDATE chirps SET created_at = $1 WHERE id = $2", start.Add(time.Duration(i)*time.Minute), id)
+					if fanOut {
+						err := q.FanOutChirp(ctx, FanOutChirpParams{ChirpID: id, FanOutThreshold: threshold})
+						if err != nil {
+							t.Fatalf("FanOutChirp() error = %v", err)
+						}
+					}
+					return id
+				}
+				older := post(friend, 0)
+				var newer []uuid.UUID
+				for i := range 2 * pageSize {
+					newer = append(newer, post(hidden, i+1))
+				}
+				hide.apply(t, conn, viewer, hidden, newer)
+
+				chirps, err := q.GetHomeTimeline(ctx, GetHomeTimelineParams{
+					UserID:          viewer,
+					CursorCreatedAt: start.Add(time.Hour),
+					CursorID:        uuid.Max,
+					PageSize:        pageSize,
+					FanOutThreshold: threshold,
+				})
+				if err != nil {
+					t.Fatalf("GetHomeTimeline() error = %v", err)
+				}
+				if len(chirps) != 1 || chirps[0].ID != older {
+					t.Errorf("GetHomeTimeline() returned %d chirps, want only the visible one", len(chirps))
+				}
+			})
+		}
+	}
+}
+
+func TestGetChirpAudience(t *testing.T) {
+	conn := openTestDB(t)
+	q := New(conn)
+	author := createTestUser(t, conn)
+	follower := createTestUser(t, conn)
+	muter := createTestUser(t, conn)
+	stranger := createTestUser(t, conn)
+	blocked := createTestUser(t, conn)
+	for _, user := range []uuid.UUID{follower, muter} {
+		execTest(t, conn, "INSERT INTO follows (follower_id, followee_id, created_at) VALUES ($1, $2, NOW())", user, author)
+	}
+	execTest(t, conn, "INSERT INTO mutes (muter_id, muted_id, created_at) VALUES ($1, $2, NOW())", muter, author)
+	execTest(t, conn, "INSERT INTO blocks (blocker_id, blocked_id, created_at) VALUES ($1, $2, NOW())", author, blocked)
+	users := []uuid.UUID{author, follower, muter, stranger, blocked}
+
+	tests := []struct {
+		visibility string
+		want       map[uuid.UUID]bool
+	}{
+		{"public", map[uuid.UUID]bool{author: true, follower: true, muter: false, stranger: false}},
+		{"followers", map[uuid.UUID]bool{author: true, follower: true, muter: false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.visibility, func(t *testing.T) {
+			chirp := createTestChirp(t, conn, author, tt.visibility)
+			rows, err := q.GetChirpAudience(context.Background(), GetChirpAudienceParams{
+				UserIds: users,
+				ChirpID: chirp,
+			})
+			if err != nil {
+				t.Fatalf("GetChirpAudience() error = %v", err)
+			}
+			got := map[uuid.UUID]bool{}
+			for _, row := range rows {
+				got[row.UserID] = row.InHome
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("GetChirpAudience() returned %d viewers, want %d", len(got), len(tt.want))
+			}
+			for user, inHome := range tt.want {
+				if got[user] != inHome {
+					t.Errorf("InHome for %v = %v, want %v", user, got[user], inHome)
+				}
+			}
+		})
+	}
+}
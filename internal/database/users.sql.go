@@ -7,27 +7,44 @@ package database
 
 import (
 	"context"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+const countUsersByIDs = `-- name: CountUsersByIDs :one
+SELECT COUNT(*) FROM users
+WHERE id = ANY($1::uuid[])
+`
+
+func (q *Queries) CountUsersByIDs(ctx context.Context, ids []uuid.UUID) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countUsersByIDs, pq.Array(ids))
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createUser = `-- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, email, hashed_password)
+INSERT INTO users (id, created_at, updated_at, email, hashed_password, invited_by)
 VALUES (
 	gen_random_uuid(),
 	NOW(),
 	NOW(),
 	$1,
-	$2
+	$2,
+	$3
 )
-RETURNING id, created_at, updated_at, email, hashed_password
+RETURNING id, created_at, updated_at, email, hashed_password, role, suspended_until, banned_at, invited_by
 `
 
 type CreateUserParams struct {
 	Email          string
 	HashedPassword string
+	InvitedBy      uuid.NullUUID
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
-	row := q.db.QueryRowContext(ctx, createUser, arg.Email, arg.HashedPassword)
+	row := q.db.QueryRowContext(ctx, createUser, arg.Email, arg.HashedPassword, arg.InvitedBy)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -35,12 +52,16 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.UpdatedAt,
 		&i.Email,
 		&i.HashedPassword,
+		&i.Role,
+		&i.SuspendedUntil,
+		&i.BannedAt,
+		&i.InvitedBy,
 	)
 	return i, err
 }
 
 const getUser = `-- name: GetUser :one
-SELECT id, created_at, updated_at, email, hashed_password FROM users 
+SELECT id, created_at, updated_at, email, hashed_password, role, suspended_until, banned_at, invited_by FROM users 
 WHERE email = $1
 `
 
@@ -53,6 +74,60 @@ func (q *Queries) GetUser(ctx context.Context, email string) (User, error) {
 		&i.UpdatedAt,
 		&i.Email,
 		&i.HashedPassword,
+		&i.Role,
+		&i.SuspendedUntil,
+		&i.BannedAt,
+		&i.InvitedBy,
+	)
+	return i, err
+}
+
+const getUserByID = `-- name: GetUserByID :one
+SELECT id, created_at, updated_at, email, hashed_password, role, suspended_until, banned_at, invited_by FROM users
+WHERE id = $1
+`
+
+func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByID, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.Role,
+		&i.SuspendedUntil,
+		&i.BannedAt,
+		&i.InvitedBy,
+	)
+	return i, err
+}
+
+const getUserForViewer = `-- name: GetUserForViewer :one
+SELECT id, created_at, updated_at, email, hashed_password, role, suspended_until, banned_at, invited_by FROM users
+WHERE id = $1
+AND NOT blocked_between(id, $2::uuid)
+`
+
+type GetUserForViewerParams struct {
+	ID       uuid.UUID
+	ViewerID uuid.UUID
+}
+
+func (q *Queries) GetUserForViewer(ctx context.Context, arg GetUserForViewerParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserForViewer, arg.ID, arg.ViewerID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.Role,
+		&i.SuspendedUntil,
+		&i.BannedAt,
+		&i.InvitedBy,
 	)
 	return i, err
 }
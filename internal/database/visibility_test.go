@@ -0,0 +1,136 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestCanViewChirp(t *testing.T) {
+	conn := openTestDB(t)
+	author := createTestUser(t, conn)
+	follower := createTestUser(t, conn)
+	mentioned := createTestUser(t, conn)
+	stranger := createTestUser(t, conn)
+	blocked := createTestUser(t, conn)
+	execTest(t, conn, "INSERT INTO follows (follower_id, followee_id, created_at) VALUES ($1, $2, NOW())", follower, author)
+	execTest(t, conn, "INSERT INTO follows (follower_id, followee_id, created_at) VALUES ($1, $2, NOW())", blocked, author)
+	execTest(t, conn, "INSERT INTO blocks (blocker_id, blocked_id, created_at) VALUES ($1, $2, NOW())", author, blocked)
+
+	public := createTestChirp(t, conn, author, "public")
+	followers := createTestChirp(t, conn, author, "followers")
+	mentions := createTestChirp(t, conn, author, "mentioned")
+	execTest(t, conn, "INSERT INTO chirp_mentions (chirp_id, user_id) VALUES ($1, $2)", mentions, mentioned)
+	execTest(t, conn, "INSERT INTO chirp_mentions (chirp_id, user_id) VALUES ($1, $2)", mentions, blocked)
+	hidden := createTestChirp(t, conn, author, "public")
+	execTest(t, conn, "INSERT INTO hidden_chirps (chirp_id, created_at) VALUES ($1, NOW())", hidden)
+	held := createTestChirp(t, conn, author, "public")
+	execTest(t, conn, "INSERT INTO held_chirps (chirp_id, created_at, score) VALUES ($1, NOW(), 0.99)", held)
+
+	shadowBanned := createTestUser(t, conn)
+	execTest(t, conn, "INSERT INTO shadow_bans (user_id, created_at) VALUES ($1, NOW())", shadowBanned)
+	shadowed := createTestChirp(t, conn, shadowBanned, "public")
+
+	tests := []struct {
+		name   string
+		chirp  uuid.UUID
+		author uuid.UUID
+		vis    string
+		viewer uuid.UUID
+		want   bool
+	}{
+		{"public to anyone", public, author, "public", stranger, true},
+		{"public to anonymous", public, author, "public", uuid.Nil, true},
+		{"public to blocked", public, author, "public", blocked, false},
+		{"followers to author", followers, author, "followers", author, true},
+		{"followers to follower", followers, author, "followers", follower, true},
+		{"followers to stranger", followers, author, "followers", stranger, false},
+		{"followers to anonymous", followers, author, "followers", uuid.Nil, false},
+		{"followers to blocked follower", followers, author, "followers", blocked, false},
+		{"mentioned to author", mentions, author, "mentioned", author, true},
+		{"mentioned to mentioned", mentions, author, "mentioned", mentioned, true},
+		{"mentioned to follower", mentions, author, "mentioned", follower, false},
+		{"mentioned to blocked mentioned", mentions, author, "mentioned", blocked, false},
+		{"hidden to author", hidden, author, "public", author, true},
+		{"hidden to stranger", hidden, author, "public", stranger, false},
+		{"held to author", held, author, "public", author, true},
+		{"held to stranger", held, author, "public", stranger, false},
+		{"shadow banned to author", shadowed, shadowBanned, "public", shadowBanned, true},
+		{"shadow banned to stranger", shadowed, shadowBanned, "public", stranger, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got bool
+			err := conn.QueryRowContext(context.Background(),
+				"SELECT can_view_chirp($1, $2, $3, $4)",
+				tt.chirp,
+				tt.author,
+				tt.vis,
+				tt.viewer,
+			).Scan(&got)
+			if err != nil {
+				t.Fatalf("can_view_chirp() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("can_view_chirp() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetMedia(t *testing.T) {
+	conn := openTestDB(t)
+	q := New(conn)
+	author := createTestUser(t, conn)
+	follower := createTestUser(t, conn)
+	stranger := createTestUser(t, conn)
+	execTest(t, conn, "INSERT INTO follows (follower_id, followee_id, created_at) VALUES ($1, $2, NOW())", follower, author)
+
+	createMedia := func(chirpID uuid.NullUUID) uuid.UUID {
+		id := uuid.New()
+		execTest(t, conn, `
+			INSERT INTO media (id, created_at, user_id, chirp_id, content_type, width, height, size_bytes, storage_key, thumbnail_key)
+			VALUES ($1, NOW(), $2, $3, 'image/png', 1, 1, 1, 'key', 'thumb')`, id, author, chirpID)
+		return id
+	}
+	unattached := createMedia(uuid.NullUUID{})
+	public := createMedia(uuid.NullUUID{UUID: createTestChirp(t, conn, author, "public"), Valid: true})
+	followers := createMedia(uuid.NullUUID{UUID: createTestChirp(t, conn, author, "followers"), Valid: true})
+
+	tests := []struct {
+		name       string
+		media      uuid.UUID
+		viewer     uuid.UUID
+		wantFound  bool
+		wantPublic bool
+	}{
+		{"unattached to uploader", unattached, author, true, false},
+		{"unattached to stranger", unattached, stranger, false, false},
+		{"unattached to anonymous", unattached, uuid.Nil, false, false},
+		{"public to anonymous", public, uuid.Nil, true, true},
+		{"public to stranger", public, stranger, true, true},
+		{"followers to follower", followers, follower, true, false},
+		{"followers to author", followers, author, true, false},
+		{"followers to stranger", followers, stranger, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			row, err := q.GetMedia(context.Background(), GetMediaParams{ID: tt.media, ViewerID: tt.viewer})
+			if !tt.wantFound {
+				if !errors.Is(err, sql.ErrNoRows) {
+					t.Fatalf("GetMedia() error = %v, want sql.ErrNoRows", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetMedia() error = %v", err)
+			}
+			if row.Public != tt.wantPublic {
+				t.Errorf("GetMedia() Public = %v, want %v", row.Public, tt.wantPublic)
+			}
+		})
+	}
+}
@@ -0,0 +1,3 @@
+module internal/images
+
+go 1.24.2
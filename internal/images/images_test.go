@@ -0,0 +1,206 @@
+package images
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+// testImage is w x h blue with a red square in its top left corner, so
+// tests can tell which way up it came out.
+func testImage(w, h int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := color.RGBA{B: 255, A: 255}
+			if x < 16 && y < 16 {
+				c = color.RGBA{R: 255, A: 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+// exifSegment builds a big-endian APP1 EXIF segment holding an orientation
+// tag and a GPS IFD with a latitude reference.
+func exifSegment(orientation uint16) []byte {
+	var tiff bytes.Buffer
+	be := binary.BigEndian
+	tiff.WriteString("MM\x00\x2a")
+	binary.Write(&tiff, be, uint32(8))
+	// IFD0: orientation and a pointer to the GPS IFD right after it.
+	binary.Write(&tiff, be, uint16(2))
+	binary.Write(&tiff, be, []uint16{0x0112, 3})
+	binary.Write(&tiff, be, uint32(1))
+	binary.Write(&tiff, be, []uint16{orientation, 0})
+	binary.Write(&tiff, be, []uint16{0x8825, 4})
+	binary.Write(&tiff, be, []uint32{1, 8 + 2 + 2*12 + 4})
+	binary.Write(&tiff, be, uint32(0))
+	// GPS IFD: GPSLatitudeRef = "N".
+	binary.Write(&tiff, be, uint16(1))
+	binary.Write(&tiff, be, []uint16{0x0001, 2})
+	binary.Write(&tiff, be, uint32(2))
+	tiff.WriteString("N\x00\x00\x00")
+	binary.Write(&tiff, be, uint32(0))
+
+	payload := append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	be.PutUint16(segment[2:], uint16(len(payload)+2))
+	return append(segment, payload...)
+}
+
+// jpegWithExif encodes img as a JPEG and splices an EXIF segment in after
+// the start of image marker.
+func jpegWithExif(t *testing.T, img image.Image, orientation uint16) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 95}); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	out := append([]byte{}, data[:2]...)
+	out = append(out, exifSegment(orientation)...)
+	return append(out, data[2:]...)
+}
+
+// segmentMarkers lists the markers of a JPEG's segments up to the start of
+// scan.
+func segmentMarkers(t *testing.T, data []byte) []byte {
+	t.Helper()
+	if len(data) < 2 || data[0] != 0xFF || data[1] != 0xD8 {
+		t.Fatal("not a JPEG")
+	}
+	var markers []byte
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			t.Fatalf("bad segment at %d", i)
+		}
+		markers = append(markers, data[i+1])
+		if data[i+1] == 0xDA {
+			break
+		}
+		i += 2 + int(binary.BigEndian.Uint16(data[i+2:]))
+	}
+	return markers
+}
+
+func isRed(c color.Color) bool {
+	r, g, b, _ := c.RGBA()
+	return r > 0xC000 && g < 0x4000 && b < 0x4000
+}
+
+func TestProcessJPEG(t *testing.T) {
+	tests := []struct {
+		name        string
+		orientation uint16
+		w, h        int
+		wantW       int
+		wantH       int
+		// corner is where the red square should end up.
+		corner image.Point
+		thumbW int
+		thumbH int
+	}{
+		{"upright", 1, 64, 32, 64, 32, image.Pt(0, 0), 64, 32},
+		{"rotated 180", 3, 64, 32, 64, 32, image.Pt(63, 31), 64, 32},
+		{"rotated 90 clockwise", 6, 64, 32, 32, 64, image.Pt(31, 0), 32, 64},
+		{"rotated 90 counterclockwise", 8, 64, 32, 32, 64, image.Pt(0, 63), 32, 64},
+		{"thumbnailed", 6, 800, 400, 400, 800, image.Pt(399, 0), 160, 320},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := jpegWithExif(t, testImage(tt.w, tt.h), tt.orientation)
+			if jpegOrientation(data) != int(tt.orientation) {
+				t.Fatalf("test JPEG has orientation %d, want %d", jpegOrientation(data), tt.orientation)
+			}
+
+			got, err := Process(data, "image/jpeg")
+			if err != nil {
+				t.Fatalf("Process() error = %v", err)
+			}
+			if got.ContentType != "image/jpeg" {
+				t.Errorf("ContentType = %q", got.ContentType)
+			}
+			if got.Width != tt.wantW || got.Height != tt.wantH {
+				t.Errorf("size = %dx%d, want %dx%d", got.Width, got.Height, tt.wantW, tt.wantH)
+			}
+			for name, blob := range map[string][]byte{"image": got.Data, "thumbnail": got.Thumbnail} {
+				if bytes.Contains(segmentMarkers(t, blob), []byte{0xE1}) {
+					t.Errorf("%s still has an APP1 segment", name)
+				}
+				if bytes.Contains(blob, []byte("Exif\x00\x00")) {
+					t.Errorf("%s still has EXIF data", name)
+				}
+			}
+
+			img, err := jpeg.Decode(bytes.NewReader(got.Data))
+			if err != nil {
+				t.Fatalf("decoding output: %v", err)
+			}
+			if b := img.Bounds(); b.Dx() != tt.wantW || b.Dy() != tt.wantH {
+				t.Errorf("decoded size = %dx%d, want %dx%d", b.Dx(), b.Dy(), tt.wantW, tt.wantH)
+			}
+			if !isRed(img.At(tt.corner.X, tt.corner.Y)) {
+				t.Errorf("pixel at %v isn't red; image wasn't turned upright", tt.corner)
+			}
+
+			thumb, err := jpeg.DecodeConfig(bytes.NewReader(got.Thumbnail))
+			if err != nil {
+				t.Fatalf("decoding thumbnail: %v", err)
+			}
+			if thumb.Width != tt.thumbW || thumb.Height != tt.thumbH {
+				t.Errorf("thumbnail size = %dx%d, want %dx%d", thumb.Width, thumb.Height, tt.thumbW, tt.thumbH)
+			}
+		})
+	}
+}
+
+func TestProcessPNG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, testImage(640, 100)); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Process(buf.Bytes(), "image/png")
+	if err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+	if got.Width != 640 || got.Height != 100 {
+		t.Errorf("size = %dx%d, want 640x100", got.Width, got.Height)
+	}
+	thumb, err := png.DecodeConfig(bytes.NewReader(got.Thumbnail))
+	if err != nil {
+		t.Fatalf("decoding thumbnail: %v", err)
+	}
+	if thumb.Width != MaxThumbnailSide || thumb.Height != 50 {
+		t.Errorf("thumbnail size = %dx%d, want %dx50", thumb.Width, thumb.Height, MaxThumbnailSide)
+	}
+}
+
+func TestProcessRejects(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, testImage(20, 20)); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name        string
+		data        []byte
+		contentType string
+	}{
+		{"gif", []byte("GIF89a"), "image/gif"},
+		{"garbage", []byte("not an image"), "image/png"},
+		{"truncated", buf.Bytes()[:len(buf.Bytes())/2], "image/png"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Process(tt.data, tt.contentType); !errors.Is(err, ErrUnsupported) {
+				t.Errorf("Process() error = %v, want ErrUnsupported", err)
+			}
+		})
+	}
+}
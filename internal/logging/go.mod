@@ -0,0 +1,3 @@
+module internal/logging
+
+go 1.24.2
@@ -0,0 +1,105 @@
+// Package logging builds the structured logger: JSON lines carrying the
+// request ID from the context, with credentials and email addresses
+// redacted wherever they turn up.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+// sensitiveKeys are attribute keys whose values are never logged. A key
+// matches if it contains any of them.
+var sensitiveKeys = []string{
+	"password",
+	"token",
+	"secret",
+	"authorization",
+	"api_key",
+	"cookie",
+	"email",
+}
+
+// sensitiveText finds credentials and addresses inside free text, such as
+// error messages that quote a query argument.
+var sensitiveText = []struct {
+	pattern *regexp.Regexp
+	replace string
+}{
+	{regexp.MustCompile(`(?i)\b(bearer|apikey)\s+\S+`), "$1 " + redacted},
+	{regexp.MustCompile(`\beyJ[\w-]*\.[\w-]+\.[\w-]+`), redacted},
+	{regexp.MustCompile(`\b[0-9a-fA-F]{64}\b`), redacted},
+	{regexp.MustCompile(`[\w.+-]+@[\w-]+(\.[\w-]+)+`), redacted},
+}
+
+type contextKey struct{}
+
+// WithRequestID returns a context carrying the request ID id.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// RequestID returns the request ID carried by ctx, or "".
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// New returns a logger writing JSON lines at level and above to w.
+func New(w io.Writer, level slog.Leveler) *slog.Logger {
+	return slog.New(&contextHandler{slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: redact,
+	})})
+}
+
+// Redact scrubs credentials and email addresses out of s.
+func Redact(s string) string {
+	for _, st := range sensitiveText {
+		s = st.pattern.ReplaceAllString(s, st.replace)
+	}
+	return s
+}
+
+func redact(groups []string, a slog.Attr) slog.Attr {
+	key := strings.ToLower(a.Key)
+	for _, sensitive := range sensitiveKeys {
+		if strings.Contains(key, sensitive) {
+			return slog.String(a.Key, redacted)
+		}
+	}
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, Redact(a.Value.String()))
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok {
+			return slog.String(a.Key, Redact(err.Error()))
+		}
+	}
+	return a
+}
+
+// contextHandler adds the request ID from the context to each record.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,74 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"no such user alice@example.com", "no such user [REDACTED]"},
+		{"header was Bearer abc.def.ghi", "header was Bearer [REDACTED]"},
+		{"ApiKey f00d", "ApiKey [REDACTED]"},
+		{"token eyJhbGciOiJIUzI1NiJ9.eyJzdWIiOiIxIn0.c2ln expired", "token [REDACTED] expired"},
+		{"refresh " + strings.Repeat("ab", 32), "refresh [REDACTED]"},
+		{"chirp 7d1f0c4e-2a5b-4c1e-9f3a-1b2c3d4e5f60 not found", "chirp 7d1f0c4e-2a5b-4c1e-9f3a-1b2c3d4e5f60 not found"},
+	}
+	for _, tt := range tests {
+		if got := Redact(tt.in); got != tt.want {
+			t.Errorf("Redact(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, slog.LevelInfo)
+	ctx := WithRequestID(context.Background(), "req-1")
+
+	logger.With("component", "test").ErrorContext(ctx, "Could not create user bob@example.com",
+		"email", "bob@example.com",
+		"Password", "hunter2",
+		"refresh_token", "abc",
+		"err", errors.New(`pq: duplicate key value (email)=(bob@example.com)`),
+		"status", 500,
+	)
+	logger.DebugContext(ctx, "dropped")
+
+	var line map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("want one JSON line, got %q: %v", buf.String(), err)
+	}
+	want := map[string]any{
+		"msg":           "Could not create user [REDACTED]",
+		"email":         "[REDACTED]",
+		"Password":      "[REDACTED]",
+		"refresh_token": "[REDACTED]",
+		"err":           "pq: duplicate key value (email)=([REDACTED])",
+		"status":        float64(500),
+		"component":     "test",
+		"request_id":    "req-1",
+	}
+	for k, v := range want {
+		if line[k] != v {
+			t.Errorf("%s = %v, want %v", k, line[k], v)
+		}
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	if id := RequestID(context.Background()); id != "" {
+		t.Errorf("RequestID = %q without one set", id)
+	}
+	if id := RequestID(WithRequestID(context.Background(), "x")); id != "x" {
+		t.Errorf("RequestID = %q, want x", id)
+	}
+}
@@ -0,0 +1,88 @@
+package moderation
+
+// matcher finds every occurrence of a set of patterns in a single pass over
+// the text using the Aho-Corasick automaton, so checking a chirp costs the
+// same however long the word list grows.
+type matcher struct {
+	nodes []acNode
+}
+
+type acNode struct {
+	next map[rune]int32
+	fail int32
+	// out holds the patterns that end at this node, including those reached
+	// through fail links.
+	out []int32
+}
+
+// acMatch is an occurrence of a pattern ending just before rune end.
+type acMatch struct {
+	pattern int32
+	end     int
+}
+
+func newMatcher(patterns [][]rune) *matcher {
+	m := &matcher{nodes: []acNode{{next: map[rune]int32{}}}}
+	for i, p := range patterns {
+		n := int32(0)
+		for _, r := range p {
+			child, ok := m.nodes[n].next[r]
+			if !ok {
+				child = int32(len(m.nodes))
+				m.nodes = append(m.nodes, acNode{next: map[rune]int32{}})
+				m.nodes[n].next[r] = child
+			}
+			n = child
+		}
+		m.nodes[n].out = append(m.nodes[n].out, int32(i))
+	}
+
+	// Breadth-first, so each node's fail link is finished before its
+	// children need it.
+	queue := make([]int32, 0, len(m.nodes))
+	for _, child := range m.nodes[0].next {
+		queue = append(queue, child)
+	}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		for r, child := range m.nodes[n].next {
+			fail := m.nodes[n].fail
+			for {
+				if next, ok := m.nodes[fail].next[r]; ok {
+					m.nodes[child].fail = next
+					break
+				}
+				if fail == 0 {
+					break
+				}
+				fail = m.nodes[fail].fail
+			}
+			failOut := m.nodes[m.nodes[child].fail].out
+			m.nodes[child].out = append(m.nodes[child].out, failOut...)
+			queue = append(queue, child)
+		}
+	}
+	return m
+}
+
+// find reports every pattern occurrence in text, in order of where they
+// end.
+func (m *matcher) find(text []rune, yield func(acMatch)) {
+	n := int32(0)
+	for i, r := range text {
+		for {
+			if next, ok := m.nodes[n].next[r]; ok {
+				n = next
+				break
+			}
+			if n == 0 {
+				break
+			}
+			n = m.nodes[n].fail
+		}
+		for _, p := range m.nodes[n].out {
+			yield(acMatch{pattern: p, end: i + 1})
+		}
+	}
+}
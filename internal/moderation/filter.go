@@ -0,0 +1,192 @@
+// Package moderation screens user-written text against a word list. Text is
+// normalized before matching so that case, accents, fullwidth forms,
+// lookalike letters and invisible characters don't let a listed word
+// through, and each word carries its own action.
+package moderation
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Action is what happens to text containing a listed word. Actions are
+// ordered by severity.
+type Action int
+
+const (
+	ActionNone Action = iota
+	// ActionMask replaces the word with asterisks.
+	ActionMask
+	// ActionFlag lets the text through unchanged but marks it for review.
+	ActionFlag
+	// ActionReject refuses the text outright.
+	ActionReject
+)
+
+func (a Action) String() string {
+	switch a {
+	case ActionMask:
+		return "mask"
+	case ActionFlag:
+		return "flag"
+	case ActionReject:
+		return "reject"
+	default:
+		return "none"
+	}
+}
+
+// ParseAction is the inverse of Action.String for the actions a rule can
+// take.
+func ParseAction(s string) (Action, error) {
+	switch s {
+	case "mask":
+		return ActionMask, nil
+	case "flag":
+		return ActionFlag, nil
+	case "reject":
+		return ActionReject, nil
+	default:
+		return ActionNone, fmt.Errorf("unknown moderation action %q", s)
+	}
+}
+
+// Rule is a listed word or phrase and what to do when it's found.
+type Rule struct {
+	Word   string
+	Action Action
+}
+
+// Match is a listed word found in the text, as it's written in the rule.
+type Match struct {
+	Word   string
+	Action Action
+}
+
+// Result is the outcome of checking text.
+type Result struct {
+	// Text is the input with masked words replaced.
+	Text string
+	// Action is the most severe action of any match.
+	Action  Action
+	Matches []Match
+}
+
+// Flagged returns the words that marked the text for review.
+func (r Result) Flagged() []string {
+	var words []string
+	for _, m := range r.Matches {
+		if m.Action == ActionFlag {
+			words = append(words, m.Word)
+		}
+	}
+	return words
+}
+
+const mask = "****"
+
+// Filter checks text against a fixed set of rules. It's safe for concurrent
+// use; to change the rules, build a new Filter and swap it in.
+type Filter struct {
+	rules []Rule
+	// lengths holds the length in runes of each rule's word.
+	lengths []int
+	matcher *matcher
+}
+
+// New builds a Filter from rules. Words are normalized, and rules that
+// normalize to nothing are ignored. When a word is listed twice the more
+// severe action wins.
+func New(rules []Rule) *Filter {
+	actions := map[string]Action{}
+	for _, rule := range rules {
+		word := Normalize(rule.Word)
+		if word == "" {
+			continue
+		}
+		actions[word] = max(actions[word], rule.Action)
+	}
+	f := &Filter{rules: make([]Rule, 0, len(actions))}
+	for word, action := range actions {
+		f.rules = append(f.rules, Rule{Word: word, Action: action})
+	}
+	slices.SortFunc(f.rules, func(a, b Rule) int {
+		return strings.Compare(a.Word, b.Word)
+	})
+
+	patterns := make([][]rune, len(f.rules))
+	f.lengths = make([]int, len(f.rules))
+	for i, rule := range f.rules {
+		patterns[i] = []rune(rule.Word)
+		f.lengths[i] = len(patterns[i])
+	}
+	f.matcher = newMatcher(patterns)
+	return f
+}
+
+// Rules returns the filter's rules with their words normalized.
+func (f *Filter) Rules() []Rule {
+	if f == nil {
+		return nil
+	}
+	return slices.Clone(f.rules)
+}
+
+// Check finds listed words in text. A word only matches on its own, not as
+// part of a longer word, but punctuation around it doesn't stop a match. A
+// nil Filter lets everything through.
+func (f *Filter) Check(text string) Result {
+	result := Result{Text: text}
+	if f == nil || len(f.rules) == 0 {
+		return result
+	}
+
+	folded := fold(text)
+	var masked [][2]int
+	seen := map[int32]bool{}
+	f.matcher.find(folded.runes, func(m acMatch) {
+		start := m.end - f.lengths[m.pattern]
+		if start > 0 && isWordRune(folded.runes[start-1]) {
+			return
+		}
+		if m.end < len(folded.runes) && isWordRune(folded.runes[m.end]) {
+			return
+		}
+		rule := f.rules[m.pattern]
+		if !seen[m.pattern] {
+			seen[m.pattern] = true
+			result.Matches = append(result.Matches, Match{Word: rule.Word, Action: rule.Action})
+		}
+		result.Action = max(result.Action, rule.Action)
+		if rule.Action == ActionMask {
+			masked = append(masked, [2]int{folded.start[start], folded.end[m.end-1]})
+		}
+	})
+	if len(masked) > 0 {
+		result.Text = maskRanges(text, masked)
+	}
+	return result
+}
+
+// maskRanges replaces each byte range of text with the mask, merging ranges
+// that overlap.
+func maskRanges(text string, ranges [][2]int) string {
+	slices.SortFunc(ranges, func(a, b [2]int) int {
+		return a[0] - b[0]
+	})
+	var b strings.Builder
+	last := 0
+	for _, r := range ranges {
+		if r[0] < last {
+			// Overlaps the range just masked, so extend it.
+			last = max(last, r[1])
+			continue
+		}
+		b.WriteString(text[last:r[0]])
+		b.WriteString(mask)
+		last = r[1]
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
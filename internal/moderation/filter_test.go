@@ -0,0 +1,136 @@
+package moderation
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	filter := New([]Rule{
+		{Word: "kerfuffle", Action: ActionMask},
+		{Word: "Sharbert", Action: ActionMask},
+		{Word: "fornax", Action: ActionReject},
+		{Word: "buy now", Action: ActionFlag},
+	})
+
+	tests := []struct {
+		name   string
+		text   string
+		want   string
+		action Action
+	}{
+		{"clean", "This is a fine chirp", "This is a fine chirp", ActionNone},
+		{"plain", "what a kerfuffle today", "what a **** today", ActionMask},
+		{"case and punctuation", "Kerfuffle! Sharbert.", "****! ****.", ActionMask},
+		{"newlines", "line one\nkerfuffle\nline three", "line one\n****\nline three", ActionMask},
+		{"inside a longer word", "kerfuffles and sharbertine", "kerfuffles and sharbertine", ActionNone},
+		{"fullwidth", "\uff4b\uff45\uff52\uff46\uff55\uff46\uff46\uff4c\uff45", "****", ActionMask},
+		{"accents", "k\u00e9rf\u00fcffle", "****", ActionMask},
+		{"combining marks", "ke\u0301rfuffle", "****", ActionMask},
+		{"zero width", "ker\u200bfuffle", "****", ActionMask},
+		{"cyrillic lookalikes", "k\u0435rfuffl\u0435", "****", ActionMask},
+		{"digits for letters", "k3rfuffl3", "****", ActionMask},
+		{"reject", "hello fornax", "hello fornax", ActionReject},
+		{"phrase across newline", "BUY\nNOW", "BUY\nNOW", ActionFlag},
+		{"most severe wins", "kerfuffle fornax", "**** fornax", ActionReject},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := filter.Check(tt.text)
+			if got.Text != tt.want {
+				t.Errorf("Check(%q).Text = %q, want %q", tt.text, got.Text, tt.want)
+			}
+			if got.Action != tt.action {
+				t.Errorf("Check(%q).Action = %v, want %v", tt.text, got.Action, tt.action)
+			}
+		})
+	}
+}
+
+func TestCheckEmptyList(t *testing.T) {
+	for _, filter := range []*Filter{nil, New(nil), New([]Rule{{Word: "  ", Action: ActionMask}})} {
+		got := filter.Check("nothing to hide here")
+		if got.Text != "nothing to hide here" || got.Action != ActionNone {
+			t.Errorf("Check with no rules = %+v", got)
+		}
+	}
+}
+
+func TestCheckOverlapping(t *testing.T) {
+	filter := New([]Rule{
+		{Word: "bad", Action: ActionMask},
+		{Word: "bad word", Action: ActionMask},
+		{Word: "word", Action: ActionFlag},
+	})
+	got := filter.Check("a bad word here")
+	if got.Text != "a **** here" {
+		t.Errorf("Text = %q", got.Text)
+	}
+	if got.Action != ActionFlag {
+		t.Errorf("Action = %v, want flag", got.Action)
+	}
+	if flagged := got.Flagged(); !slices.Equal(flagged, []string{"word"}) {
+		t.Errorf("Flagged() = %v", flagged)
+	}
+}
+
+func TestNewMergesDuplicates(t *testing.T) {
+	filter := New([]Rule{
+		{Word: "Fornax", Action: ActionMask},
+		{Word: "fornax ", Action: ActionReject},
+	})
+	want := []Rule{{Word: "fornax", Action: ActionReject}}
+	if got := filter.Rules(); !slices.Equal(got, want) {
+		t.Errorf("Rules() = %v, want %v", got, want)
+	}
+}
+
+func TestParseAction(t *testing.T) {
+	for _, action := range []Action{ActionMask, ActionFlag, ActionReject} {
+		got, err := ParseAction(action.String())
+		if err != nil || got != action {
+			t.Errorf("ParseAction(%q) = %v, %v", action.String(), got, err)
+		}
+	}
+	if _, err := ParseAction("none"); err == nil {
+		t.Error("ParseAction(\"none\") succeeded")
+	}
+}
+
+// benchmarkRules is a word list of a realistic size.
+func benchmarkRules() []Rule {
+	rules := make([]Rule, 0, 1000)
+	for i := range 1000 {
+		rules = append(rules, Rule{Word: fmt.Sprintf("badword%c%c", 'a'+i%26, 'a'+i/26), Action: ActionMask})
+	}
+	return rules
+}
+
+const benchmarkText = "Just got back from the farmers market and the tomatoes this year are " +
+	"unbelievable, honestly the best I've had. Anyone else going on Saturday? badwordzz"
+
+func BenchmarkCheck(b *testing.B) {
+	filter := New(benchmarkRules())
+	b.ReportAllocs()
+	for b.Loop() {
+		filter.Check(benchmarkText)
+	}
+}
+
+// BenchmarkNaive checks each word against each listed word in turn, for
+// comparison with the automaton.
+func BenchmarkNaive(b *testing.B) {
+	rules := benchmarkRules()
+	b.ReportAllocs()
+	for b.Loop() {
+		for _, word := range strings.Fields(strings.ToLower(benchmarkText)) {
+			for _, rule := range rules {
+				if word == rule.Word {
+					break
+				}
+			}
+		}
+	}
+}
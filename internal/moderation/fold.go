@@ -0,0 +1,99 @@
+package moderation
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// confusables maps characters that are commonly swapped in to dodge filters
+// onto the Latin letters they imitate.
+var confusables = map[rune]rune{
+	// Digits used as letters.
+	'0': 'o', '1': 'i', '3': 'e', '4': 'a', '5': 's', '7': 't',
+	// Cyrillic.
+	'а': 'a', 'в': 'b', 'е': 'e', 'ё': 'e', 'к': 'k', 'м': 'm', 'н': 'h',
+	'о': 'o', 'р': 'p', 'с': 'c', 'т': 't', 'у': 'y', 'х': 'x', 'і': 'i',
+	'ј': 'j', 'ѕ': 's',
+	// Greek.
+	'α': 'a', 'β': 'b', 'ε': 'e', 'η': 'n', 'ι': 'i', 'κ': 'k', 'ν': 'v',
+	'ο': 'o', 'ρ': 'p', 'τ': 't', 'υ': 'u', 'χ': 'x',
+}
+
+// folded is text reduced to the form words are matched in. Each rune keeps
+// the byte range of the original text it came from, so matches can be
+// masked in place.
+type folded struct {
+	runes []rune
+	start []int
+	end   []int
+}
+
+func (f *folded) add(r rune, start, end int) {
+	f.runes = append(f.runes, r)
+	f.start = append(f.start, start)
+	f.end = append(f.end, end)
+}
+
+// fold applies NFKC normalization to text one character at a time, then
+// lowercases it, removes accents, invisible formatting characters and
+// lookalikes, and collapses whitespace to single spaces.
+func fold(text string) folded {
+	f := folded{
+		runes: make([]rune, 0, len(text)),
+		start: make([]int, 0, len(text)),
+		end:   make([]int, 0, len(text)),
+	}
+	for i, r := range text {
+		end := i + utf8.RuneLen(r)
+		if r == utf8.RuneError {
+			end = i + 1
+		}
+		if r < utf8.RuneSelf {
+			// ASCII needs no normalization, which keeps the common case fast.
+			f.addFolded(r, i, end)
+			continue
+		}
+		// NFD after NFKC splits accented letters into a base letter and
+		// combining marks, which addFolded drops.
+		for _, nr := range norm.NFD.String(norm.NFKC.String(string(r))) {
+			f.addFolded(nr, i, end)
+		}
+	}
+	return f
+}
+
+func (f *folded) addFolded(r rune, start, end int) {
+	switch {
+	case unicode.IsSpace(r):
+		if len(f.runes) > 0 && f.runes[len(f.runes)-1] == ' ' {
+			f.end[len(f.end)-1] = end
+			return
+		}
+		r = ' '
+	case unicode.Is(unicode.Mn, r), unicode.Is(unicode.Cf, r):
+		// Combining marks, zero-width characters and bidi controls.
+		return
+	default:
+		r = unicode.ToLower(r)
+		if c, ok := confusables[r]; ok {
+			r = c
+		}
+	}
+	f.add(r, start, end)
+}
+
+// Normalize returns word in the form it's matched in. Word lists should be
+// stored normalized so that entries differing only in case or accents
+// collapse into one.
+func Normalize(word string) string {
+	return strings.TrimSpace(string(fold(word).runes))
+}
+
+// isWordRune reports whether r can continue a word, so a match next to it
+// isn't a whole word.
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsNumber(r)
+}
@@ -0,0 +1,3 @@
+module internal/pow
+
+go 1.24.2
@@ -0,0 +1,149 @@
+// Package pow issues and checks hashcash-style proof-of-work challenges.
+//
+// A challenge is a signed, expiring string naming a difficulty in bits. The
+// client searches for a solution such that the SHA-256 of
+// "<challenge>:<solution>" starts with that many zero bits, which takes
+// about 2^bits hashes to find and one to check. Challenges are stateless;
+// the caller must remember spent ones until they expire to stop replays.
+package pow
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const version = "pow1"
+
+// MaxBits bounds the difficulty of any challenge; beyond it solving would
+// take ordinary clients far too long.
+const MaxBits = 32
+
+var (
+	ErrMalformed = errors.New("malformed challenge")
+	ErrSignature = errors.New("challenge signature is invalid")
+	ErrExpired   = errors.New("challenge has expired")
+	ErrSolution  = errors.New("solution doesn't meet the challenge's difficulty")
+)
+
+// Challenge is a decoded challenge.
+type Challenge struct {
+	Bits      int
+	ExpiresAt time.Time
+}
+
+// Issue returns a new challenge of the given difficulty, valid until
+// expiresAt and signed with secret.
+func Issue(secret []byte, bits int, expiresAt time.Time) (string, error) {
+	if bits < 0 || bits > MaxBits {
+		return "", fmt.Errorf("difficulty %d is outside 0-%d bits", bits, MaxBits)
+	}
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	payload := strings.Join([]string{
+		version,
+		strconv.Itoa(bits),
+		strconv.FormatInt(expiresAt.Unix(), 10),
+		base64.RawURLEncoding.EncodeToString(nonce),
+	}, ".")
+	return payload + "." + sign(secret, payload), nil
+}
+
+// Parse checks a challenge's signature and expiry and decodes it.
+func Parse(secret []byte, challenge string, now time.Time) (Challenge, error) {
+	payload, signature, ok := cutLast(challenge, ".")
+	if !ok {
+		return Challenge{}, ErrMalformed
+	}
+	parts := strings.Split(payload, ".")
+	if len(parts) != 4 || parts[0] != version {
+		return Challenge{}, ErrMalformed
+	}
+	if !hmac.Equal([]byte(signature), []byte(sign(secret, payload))) {
+		return Challenge{}, ErrSignature
+	}
+	bits, err := strconv.Atoi(parts[1])
+	if err != nil || bits < 0 || bits > MaxBits {
+		return Challenge{}, ErrMalformed
+	}
+	expires, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return Challenge{}, ErrMalformed
+	}
+	c := Challenge{Bits: bits, ExpiresAt: time.Unix(expires, 0)}
+	if !now.Before(c.ExpiresAt) {
+		return Challenge{}, ErrExpired
+	}
+	return c, nil
+}
+
+// Verify parses challenge and checks that solution solves it.
+func Verify(secret []byte, challenge, solution string, now time.Time) (Challenge, error) {
+	c, err := Parse(secret, challenge, now)
+	if err != nil {
+		return Challenge{}, err
+	}
+	if leadingZeroBits(challenge, solution) < c.Bits {
+		return Challenge{}, ErrSolution
+	}
+	return c, nil
+}
+
+// Solve finds a solution to challenge by brute force, as a client would.
+// It gives up after maxTries attempts.
+func Solve(challenge string, bits int, maxTries uint64) (string, bool) {
+	for i := uint64(0); i < maxTries; i++ {
+		solution := strconv.FormatUint(i, 10)
+		if leadingZeroBits(challenge, solution) >= bits {
+			return solution, true
+		}
+	}
+	return "", false
+}
+
+// Difficulty scales base up by a bit each time recent doubles past
+// threshold, so a spike in signups makes each one twice as expensive per
+// doubling. It never exceeds MaxBits.
+func Difficulty(base, recent, threshold int) int {
+	if threshold <= 0 || recent <= threshold {
+		return min(base, MaxBits)
+	}
+	extra := int(math.Floor(math.Log2(float64(recent)/float64(threshold)))) + 1
+	return min(base+extra, MaxBits)
+}
+
+func leadingZeroBits(challenge, solution string) int {
+	sum := sha256.Sum256([]byte(challenge + ":" + solution))
+	n := 0
+	for _, b := range sum {
+		if b != 0 {
+			return n + bits.LeadingZeros8(b)
+		}
+		n += 8
+	}
+	return n
+}
+
+func sign(secret []byte, payload string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func cutLast(s, sep string) (before, after string, found bool) {
+	i := strings.LastIndex(s, sep)
+	if i < 0 {
+		return s, "", false
+	}
+	return s[:i], s[i+len(sep):], true
+}
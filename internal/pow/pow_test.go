@@ -0,0 +1,84 @@
+package pow
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+var secret = []byte("test secret")
+
+func TestIssueAndVerify(t *testing.T) {
+	now := time.Now()
+	challenge, err := Issue(secret, 12, now.Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	solution, ok := Solve(challenge, 12, 1<<24)
+	if !ok {
+		t.Fatal("Solve gave up")
+	}
+	c, err := Verify(secret, challenge, solution, now)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if c.Bits != 12 {
+		t.Errorf("Bits = %d", c.Bits)
+	}
+}
+
+func TestVerifyRejects(t *testing.T) {
+	now := time.Now()
+	challenge, err := Issue(secret, 16, now.Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	solution, _ := Solve(challenge, 16, 1<<24)
+
+	// An arbitrary string is very unlikely to clear 16 bits by chance.
+	if weak := "x"; leadingZeroBits(challenge, weak) < 16 {
+		if _, err := Verify(secret, challenge, weak, now); !errors.Is(err, ErrSolution) {
+			t.Errorf("weak solution: err = %v", err)
+		}
+	}
+	if _, err := Verify(secret, challenge, solution, now.Add(time.Hour)); !errors.Is(err, ErrExpired) {
+		t.Errorf("expired: err = %v", err)
+	}
+	if _, err := Verify([]byte("other secret"), challenge, solution, now); !errors.Is(err, ErrSignature) {
+		t.Errorf("wrong secret: err = %v", err)
+	}
+	// Lowering the difficulty invalidates the signature.
+	tampered := strings.Replace(challenge, "pow1.16.", "pow1.1.", 1)
+	if _, err := Verify(secret, tampered, solution, now); !errors.Is(err, ErrSignature) {
+		t.Errorf("tampered: err = %v", err)
+	}
+	if _, err := Verify(secret, "nonsense", solution, now); !errors.Is(err, ErrMalformed) {
+		t.Errorf("malformed: err = %v", err)
+	}
+}
+
+func TestIssueBounds(t *testing.T) {
+	if _, err := Issue(secret, MaxBits+1, time.Now()); err == nil {
+		t.Error("Issue accepted a difficulty over MaxBits")
+	}
+}
+
+func TestDifficulty(t *testing.T) {
+	tests := []struct {
+		recent int
+		want   int
+	}{
+		{0, 18},
+		{100, 18},
+		{101, 19},
+		{200, 20},
+		{400, 21},
+		{1_000_000, MaxBits},
+	}
+	for _, tt := range tests {
+		if got := Difficulty(18, tt.recent, 100); got != tt.want {
+			t.Errorf("Difficulty(18, %d, 100) = %d, want %d", tt.recent, got, tt.want)
+		}
+	}
+}
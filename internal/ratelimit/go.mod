@@ -0,0 +1,3 @@
+module internal/ratelimit
+
+go 1.24.2
@@ -0,0 +1,56 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// pruneInterval is how often Memory drops buckets that have refilled.
+const pruneInterval = time.Minute
+
+type entry struct {
+	bucket Bucket
+	fullAt time.Time
+}
+
+// Memory keeps buckets in the process. Each instance counts on its own, so
+// it only suits a single server.
+type Memory struct {
+	mu        sync.Mutex
+	buckets   map[string]entry
+	nextPrune time.Time
+}
+
+func NewMemory() *Memory {
+	return &Memory{buckets: map[string]entry{}}
+}
+
+func (m *Memory) Take(ctx context.Context, key string, p Policy, now time.Time) (Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if now.After(m.nextPrune) {
+		for k, e := range m.buckets {
+			if !now.Before(e.fullAt) {
+				delete(m.buckets, k)
+			}
+		}
+		m.nextPrune = now.Add(pruneInterval)
+	}
+
+	e, ok := m.buckets[key]
+	if !ok {
+		e.bucket = p.NewBucket(now)
+	}
+	bucket, res := p.Take(e.bucket, now)
+	m.buckets[key] = entry{bucket: bucket, fullAt: p.FullAt(bucket)}
+	return res, nil
+}
+
+// Len is how many buckets are held.
+func (m *Memory) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.buckets)
+}
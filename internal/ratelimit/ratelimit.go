@@ -0,0 +1,168 @@
+// Package ratelimit implements token-bucket rate limits.
+//
+// Each key has a bucket holding up to Policy.Limit tokens, refilled evenly
+// over Policy.Period. A request spends one token and is refused when the
+// bucket is empty, so clients can burst up to the limit and then sustain
+// Limit requests per Period. Buckets live in a Store; Memory keeps them in
+// the process, and callers can keep them anywhere else that can run Take
+// atomically per key.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Policy is how many requests a key can make in a period.
+type Policy struct {
+	Limit  int
+	Period time.Duration
+}
+
+// Bucket is the stored state of one key.
+type Bucket struct {
+	Tokens    float64
+	UpdatedAt time.Time
+}
+
+// Result is the outcome of taking a token.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is how long until the next token, when refused.
+	RetryAfter time.Duration
+}
+
+// Store keeps buckets and takes tokens from them.
+type Store interface {
+	Take(ctx context.Context, key string, p Policy, now time.Time) (Result, error)
+}
+
+var units = map[string]time.Duration{
+	"s": time.Second,
+	"m": time.Minute,
+	"h": time.Hour,
+	"d": 24 * time.Hour,
+}
+
+// ParsePolicy reads a policy written as "<limit>/<unit>", where the unit is
+// s, m, h or d, optionally with a count: "5/m", "100/15m".
+func ParsePolicy(s string) (Policy, error) {
+	limit, period, ok := strings.Cut(strings.TrimSpace(s), "/")
+	if !ok {
+		return Policy{}, fmt.Errorf("policy %q isn't <limit>/<period>", s)
+	}
+	n, err := strconv.Atoi(limit)
+	if err != nil || n <= 0 {
+		return Policy{}, fmt.Errorf("policy %q needs a positive limit", s)
+	}
+	if period == "" {
+		return Policy{}, fmt.Errorf("policy %q needs a period", s)
+	}
+	unit, ok := units[period[len(period)-1:]]
+	if !ok {
+		return Policy{}, fmt.Errorf("policy %q has an unknown unit", s)
+	}
+	count := 1
+	if period[:len(period)-1] != "" {
+		count, err = strconv.Atoi(period[:len(period)-1])
+		if err != nil || count <= 0 {
+			return Policy{}, fmt.Errorf("policy %q has an invalid period", s)
+		}
+	}
+	return Policy{Limit: n, Period: time.Duration(count) * unit}, nil
+}
+
+// String returns the policy in the form of a RateLimit-Policy header.
+func (p Policy) String() string {
+	return fmt.Sprintf("%d;w=%d", p.Limit, int(p.Period.Seconds()))
+}
+
+// NewBucket returns a full bucket for p.
+func (p Policy) NewBucket(now time.Time) Bucket {
+	return Bucket{Tokens: float64(p.Limit), UpdatedAt: now}
+}
+
+// Take refills b for the time since it was last updated and spends a token
+// if there is one. It returns the bucket to store back.
+func (p Policy) Take(b Bucket, now time.Time) (Bucket, Result) {
+	perToken := p.Period / time.Duration(p.Limit)
+	elapsed := max(now.Sub(b.UpdatedAt), 0)
+	tokens := math.Min(float64(p.Limit), b.Tokens+float64(elapsed)/float64(perToken))
+
+	res := Result{Limit: p.Limit}
+	if tokens >= 1 {
+		tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = time.Duration((1 - tokens) * float64(perToken))
+	}
+	res.Remaining = int(tokens)
+	res.Reset = time.Duration((float64(p.Limit) - tokens) * float64(perToken))
+	return Bucket{Tokens: tokens, UpdatedAt: now}, res
+}
+
+// FullAt is when a bucket left at b would be full again, after which it can
+// be dropped without changing any result.
+func (p Policy) FullAt(b Bucket) time.Time {
+	perToken := p.Period / time.Duration(p.Limit)
+	return b.UpdatedAt.Add(time.Duration((float64(p.Limit) - b.Tokens) * float64(perToken)))
+}
+
+// Scope is what requests are counted against.
+type Scope string
+
+const (
+	ByIP     Scope = "ip"
+	ByUser   Scope = "user"
+	ByAPIKey Scope = "api_key"
+)
+
+// Rule is a policy and the scope it's counted in.
+type Rule struct {
+	Policy Policy
+	Scope  Scope
+}
+
+// ParseRules reads rules written as "<route>=<policy>:<scope>" separated by
+// semicolons, for example "POST /api/login=5/m:ip;*=300/m:user". The scope
+// defaults to ip.
+func ParseRules(s string) (map[string]Rule, error) {
+	rules := map[string]Rule{}
+	for _, entry := range strings.Split(s, ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		route, value, ok := strings.Cut(entry, "=")
+		route = strings.TrimSpace(route)
+		if !ok || route == "" {
+			return nil, fmt.Errorf("rule %q isn't <route>=<policy>", entry)
+		}
+		policy, scope, _ := strings.Cut(value, ":")
+		rule := Rule{Scope: ByIP}
+		if scope = strings.TrimSpace(scope); scope != "" {
+			rule.Scope = Scope(scope)
+		}
+		if rule.Scope != ByIP && rule.Scope != ByUser && rule.Scope != ByAPIKey {
+			return nil, fmt.Errorf("rule %q has an unknown scope", entry)
+		}
+		var err error
+		rule.Policy, err = ParsePolicy(policy)
+		if err != nil {
+			return nil, err
+		}
+		rules[route] = rule
+	}
+	if len(rules) == 0 {
+		return nil, errors.New("no rate limit rules")
+	}
+	return rules, nil
+}
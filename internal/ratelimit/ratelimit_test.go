@@ -0,0 +1,130 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestParsePolicy(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Policy
+	}{
+		{"5/m", Policy{5, time.Minute}},
+		{"100/15m", Policy{100, 15 * time.Minute}},
+		{" 10/s ", Policy{10, time.Second}},
+		{"1000/d", Policy{1000, 24 * time.Hour}},
+	}
+	for _, tt := range tests {
+		got, err := ParsePolicy(tt.in)
+		if err != nil {
+			t.Errorf("ParsePolicy(%q): %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParsePolicy(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+	for _, in := range []string{"", "5", "0/m", "-1/m", "5/", "5/x", "5/0m", "five/m"} {
+		if _, err := ParsePolicy(in); err == nil {
+			t.Errorf("ParsePolicy(%q) succeeded", in)
+		}
+	}
+}
+
+func TestTake(t *testing.T) {
+	p := Policy{Limit: 3, Period: 3 * time.Second}
+	now := time.Now()
+	b := p.NewBucket(now)
+
+	for i := 2; i >= 0; i-- {
+		var res Result
+		b, res = p.Take(b, now)
+		if !res.Allowed || res.Remaining != i {
+			t.Fatalf("take: %+v, want allowed with %d remaining", res, i)
+		}
+	}
+	b, res := p.Take(b, now)
+	if res.Allowed {
+		t.Fatal("take from an empty bucket was allowed")
+	}
+	if res.RetryAfter != time.Second || res.Reset != 3*time.Second {
+		t.Errorf("RetryAfter = %v, Reset = %v", res.RetryAfter, res.Reset)
+	}
+
+	// A token comes back every second.
+	b, res = p.Take(b, now.Add(time.Second))
+	if !res.Allowed || res.Remaining != 0 {
+		t.Errorf("take after refill: %+v", res)
+	}
+	// The bucket never holds more than the limit.
+	_, res = p.Take(b, now.Add(time.Hour))
+	if res.Remaining != 2 {
+		t.Errorf("Remaining = %d after a long wait", res.Remaining)
+	}
+}
+
+func TestFullAt(t *testing.T) {
+	p := Policy{Limit: 10, Period: 10 * time.Second}
+	now := time.Now()
+	b, _ := p.Take(p.NewBucket(now), now)
+	b, _ = p.Take(b, now)
+	if got := p.FullAt(b); !got.Equal(now.Add(2 * time.Second)) {
+		t.Errorf("FullAt = %v, want %v", got.Sub(now), 2*time.Second)
+	}
+}
+
+func TestMemory(t *testing.T) {
+	m := NewMemory()
+	p := Policy{Limit: 2, Period: time.Minute}
+	now := time.Now()
+	ctx := context.Background()
+
+	for i, want := range []bool{true, true, false} {
+		res, err := m.Take(ctx, "a", p, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Allowed != want {
+			t.Errorf("take %d: Allowed = %v", i, res.Allowed)
+		}
+	}
+	// Keys don't share buckets.
+	if res, _ := m.Take(ctx, "b", p, now); !res.Allowed {
+		t.Error("another key was limited")
+	}
+
+	// Once both have refilled, the next take prunes them.
+	later := now.Add(time.Hour)
+	m.Take(ctx, "c", p, later)
+	if m.Len() != 1 {
+		t.Errorf("Len = %d after pruning, want 1", m.Len())
+	}
+}
+
+func TestParseRules(t *testing.T) {
+	rules, err := ParseRules("POST /api/login=5/m:ip; POST /api/chirps=30/m:user;*=300/m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]Rule{
+		"POST /api/login":  {Policy{5, time.Minute}, ByIP},
+		"POST /api/chirps": {Policy{30, time.Minute}, ByUser},
+		"*":                {Policy{300, time.Minute}, ByIP},
+	}
+	if len(rules) != len(want) {
+		t.Fatalf("got %d rules, want %d", len(rules), len(want))
+	}
+	for route, rule := range want {
+		if rules[route] != rule {
+			t.Errorf("rules[%q] = %+v, want %+v", route, rules[route], rule)
+		}
+	}
+
+	for _, in := range []string{"", "POST /api/login", "=5/m", "*=5/m:host", "*=nope"} {
+		if _, err := ParseRules(in); err == nil {
+			t.Errorf("ParseRules(%q) succeeded", in)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package spam
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+)
+
+const (
+	ham = iota
+	spam
+)
+
+// Model is a multinomial naive Bayes classifier over chirp text. It's
+// trained offline from moderator labels and loaded read-only by the server.
+type Model struct {
+	docs   [2]int
+	totals [2]int
+	counts [2]map[string]int
+	// vocabulary is how many distinct words either class has seen.
+	vocabulary int
+}
+
+// NewModel returns an untrained model, which scores everything as even
+// odds.
+func NewModel() *Model {
+	return &Model{counts: [2]map[string]int{{}, {}}}
+}
+
+// Train adds one labelled text to the model.
+func (m *Model) Train(text string, isSpam bool) {
+	class := ham
+	if isSpam {
+		class = spam
+	}
+	m.docs[class]++
+	for _, token := range tokenize(text) {
+		m.add(class, token, 1)
+	}
+}
+
+// add counts n more of token in class.
+func (m *Model) add(class int, token string, n int) {
+	if m.counts[ham][token] == 0 && m.counts[spam][token] == 0 {
+		m.vocabulary++
+	}
+	m.counts[class][token] += n
+	m.totals[class] += n
+}
+
+// LogOdds returns log(P(spam|text) / P(ham|text)), using Laplace smoothing
+// for words the model hasn't seen in one class. It's 0 until the model has
+// examples of both classes.
+func (m *Model) LogOdds(text string) float64 {
+	if m == nil || m.docs[ham] == 0 || m.docs[spam] == 0 {
+		return 0
+	}
+	odds := math.Log(float64(m.docs[spam])) - math.Log(float64(m.docs[ham]))
+	for _, token := range tokenize(text) {
+		// Words seen in neither class say nothing either way.
+		if m.counts[ham][token] == 0 && m.counts[spam][token] == 0 {
+			continue
+		}
+		pSpam := float64(m.counts[spam][token]+1) / float64(m.totals[spam]+m.vocabulary)
+		pHam := float64(m.counts[ham][token]+1) / float64(m.totals[ham]+m.vocabulary)
+		odds += math.Log(pSpam) - math.Log(pHam)
+	}
+	return odds
+}
+
+// Probability returns P(spam|text).
+func (m *Model) Probability(text string) float64 {
+	return sigmoid(m.LogOdds(text))
+}
+
+// modelFile is the saved form of a Model.
+type modelFile struct {
+	HamDocs    int            `json:"ham_docs"`
+	SpamDocs   int            `json:"spam_docs"`
+	HamCounts  map[string]int `json:"ham_counts"`
+	SpamCounts map[string]int `json:"spam_counts"`
+}
+
+// Save writes the model as JSON.
+func (m *Model) Save(w io.Writer) error {
+	return json.NewEncoder(w).Encode(modelFile{
+		HamDocs:    m.docs[ham],
+		SpamDocs:   m.docs[spam],
+		HamCounts:  m.counts[ham],
+		SpamCounts: m.counts[spam],
+	})
+}
+
+// Load reads a model written by Save.
+func Load(r io.Reader) (*Model, error) {
+	var f modelFile
+	if err := json.NewDecoder(r).Decode(&f); err != nil {
+		return nil, fmt.Errorf("decoding spam model: %w", err)
+	}
+	m := NewModel()
+	m.docs = [2]int{f.HamDocs, f.SpamDocs}
+	for class, counts := range [2]map[string]int{f.HamCounts, f.SpamCounts} {
+		for token, n := range counts {
+			if n <= 0 {
+				return nil, fmt.Errorf("spam model has count %d for %q", n, token)
+			}
+			m.add(class, token, n)
+		}
+	}
+	return m, nil
+}
+
+func sigmoid(x float64) float64 {
+	return 1 / (1 + math.Exp(-x))
+}
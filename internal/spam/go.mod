@@ -0,0 +1,3 @@
+module internal/spam
+
+go 1.24.2
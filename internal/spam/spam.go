@@ -0,0 +1,108 @@
+// Package spam scores new chirps for how likely they are to be spam,
+// combining a naive Bayes model of the text with signals about how it was
+// posted.
+package spam
+
+import "math"
+
+// Features is what a Scorer knows about a new chirp.
+type Features struct {
+	Text string
+	// Duplicates is how many other recent chirps have exactly the same
+	// text.
+	Duplicates int
+	// Recent is how many chirps the author posted in the last few minutes.
+	Recent int
+}
+
+// Scorer rates a chirp from 0 (fine) to 1 (certainly spam).
+type Scorer interface {
+	Score(f Features) float64
+}
+
+// Verdict is what to do with a chirp given its score.
+type Verdict int
+
+const (
+	Allow Verdict = iota
+	// RateLimit lets the chirp through only if the author isn't posting
+	// quickly.
+	RateLimit
+	// Hold keeps the chirp from everyone but its author until a moderator
+	// looks at it.
+	Hold
+)
+
+func (v Verdict) String() string {
+	switch v {
+	case RateLimit:
+		return "rate_limit"
+	case Hold:
+		return "hold"
+	default:
+		return "allow"
+	}
+}
+
+// Thresholds are the scores at which chirps are rate-limited and held.
+type Thresholds struct {
+	RateLimit float64
+	Hold      float64
+}
+
+var DefaultThresholds = Thresholds{RateLimit: 0.6, Hold: 0.9}
+
+func (t Thresholds) Verdict(score float64) Verdict {
+	switch {
+	case score >= t.Hold:
+		return Hold
+	case score >= t.RateLimit:
+		return RateLimit
+	default:
+		return Allow
+	}
+}
+
+// Heuristic weights, in log-odds added to the model's.
+const (
+	// Each repeat of the same text elsewhere, up to maxDuplicates. Short
+	// texts like "good morning" repeat innocently and don't count.
+	duplicateWeight    = 1.5
+	maxDuplicates      = 3
+	minDuplicateLength = 20
+	// Each chirp beyond velocityAllowance in the window, up to
+	// maxVelocityWeight in total.
+	velocityWeight    = 0.5
+	velocityAllowance = 5
+	maxVelocityWeight = 3
+	// Text that is mostly links, and text with many of them.
+	linkDensityWeight = 2
+	linkDensityLimit  = 0.5
+	manyLinksWeight   = 1.5
+	manyLinks         = 3
+)
+
+// Classifier is the default Scorer. A nil Model scores on the heuristics
+// alone.
+type Classifier struct {
+	Model *Model
+}
+
+func (c Classifier) Score(f Features) float64 {
+	odds := c.Model.LogOdds(f.Text)
+
+	if len([]rune(f.Text)) >= minDuplicateLength {
+		odds += duplicateWeight * float64(min(f.Duplicates, maxDuplicates))
+	}
+	if f.Recent > velocityAllowance {
+		odds += math.Min(velocityWeight*float64(f.Recent-velocityAllowance), maxVelocityWeight)
+	}
+	links, density := linkDensity(f.Text)
+	if links > 0 && density >= linkDensityLimit {
+		odds += linkDensityWeight
+	}
+	if links >= manyLinks {
+		odds += manyLinksWeight
+	}
+	return sigmoid(odds)
+}
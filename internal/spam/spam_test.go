@@ -0,0 +1,117 @@
+package spam
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	got := tokenize("Buy NOW at https://www.Example.com/deal!! a 100% deal")
+	want := []string{"buy", "now", "at", urlToken, "host:example.com", "100", "deal"}
+	if !slices.Equal(got, want) {
+		t.Errorf("tokenize = %q, want %q", got, want)
+	}
+}
+
+func trainedModel() *Model {
+	m := NewModel()
+	for _, text := range []string{
+		"cheap followers buy now https://spam.example",
+		"win free crypto click https://spam.example",
+		"buy cheap watches now",
+	} {
+		m.Train(text, true)
+	}
+	for _, text := range []string{
+		"had a lovely walk in the park today",
+		"does anyone know a good book about gardening",
+		"the park was busy this morning",
+	} {
+		m.Train(text, false)
+	}
+	return m
+}
+
+func TestModel(t *testing.T) {
+	m := trainedModel()
+	if p := m.Probability("buy cheap followers now"); p < 0.9 {
+		t.Errorf("Probability of spammy text = %f", p)
+	}
+	if p := m.Probability("a walk in the park"); p > 0.1 {
+		t.Errorf("Probability of ordinary text = %f", p)
+	}
+	if p := NewModel().Probability("buy cheap followers now"); p != 0.5 {
+		t.Errorf("untrained Probability = %f, want 0.5", p)
+	}
+	var nilModel *Model
+	if odds := nilModel.LogOdds("anything"); odds != 0 {
+		t.Errorf("nil model LogOdds = %f", odds)
+	}
+}
+
+func TestVocabulary(t *testing.T) {
+	m := NewModel()
+	m.Train("cheap pills", true)
+	m.Train("cheap lunch", false)
+	m.Train("lunch in the park", false)
+	if m.vocabulary != 6 {
+		t.Errorf("vocabulary = %d, want 6", m.vocabulary)
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	m := trainedModel()
+	var buf bytes.Buffer
+	if err := m.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.vocabulary != m.vocabulary {
+		t.Errorf("vocabulary after reload = %d, want %d", loaded.vocabulary, m.vocabulary)
+	}
+	for _, text := range []string{"buy cheap followers now", "a walk in the park"} {
+		if got, want := loaded.LogOdds(text), m.LogOdds(text); got != want {
+			t.Errorf("LogOdds(%q) after reload = %f, want %f", text, got, want)
+		}
+	}
+	if _, err := Load(strings.NewReader(`{"ham_counts": {"x": -1}}`)); err == nil {
+		t.Error("Load accepted a negative count")
+	}
+}
+
+func TestClassifier(t *testing.T) {
+	c := Classifier{}
+	long := "check out my new blog post about cooking"
+	tests := []struct {
+		name string
+		f    Features
+		want Verdict
+	}{
+		{"ordinary", Features{Text: long}, Allow},
+		{"short duplicate", Features{Text: "good morning", Duplicates: 10}, Allow},
+		{"one duplicate", Features{Text: long, Duplicates: 1}, RateLimit},
+		{"many duplicates", Features{Text: long, Duplicates: 5}, Hold},
+		{"just a link", Features{Text: "https://a.example"}, RateLimit},
+		{"link farm", Features{Text: "https://a.example https://b.example https://c.example"}, Hold},
+		{"fast poster", Features{Text: long, Recent: 8}, RateLimit},
+		{"some chirps", Features{Text: long, Recent: 4}, Allow},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score := c.Score(tt.f)
+			if got := DefaultThresholds.Verdict(score); got != tt.want {
+				t.Errorf("verdict = %v (score %f), want %v", got, score, tt.want)
+			}
+		})
+	}
+
+	trained := Classifier{Model: trainedModel()}
+	if got := DefaultThresholds.Verdict(trained.Score(Features{Text: "win free crypto buy now"})); got != Hold {
+		t.Errorf("trained verdict on spam = %v, want hold", got)
+	}
+}
@@ -0,0 +1,66 @@
+package spam
+
+import (
+	"strings"
+	"unicode"
+)
+
+// urlToken stands in for every link, so the model learns how spammy links
+// are in general rather than one address at a time.
+const urlToken = "<url>"
+
+// tokenize splits text into the lowercase words the model counts. Links
+// become urlToken plus a token for their host.
+func tokenize(text string) []string {
+	var tokens []string
+	for _, field := range strings.Fields(text) {
+		if host, ok := linkHost(field); ok {
+			tokens = append(tokens, urlToken)
+			if host != "" {
+				tokens = append(tokens, "host:"+host)
+			}
+			continue
+		}
+		words := strings.FieldsFunc(strings.ToLower(field), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+		})
+		for _, word := range words {
+			if len([]rune(word)) > 1 {
+				tokens = append(tokens, word)
+			}
+		}
+	}
+	return tokens
+}
+
+// linkHost reports whether field is a link and returns its lowercase host.
+func linkHost(field string) (string, bool) {
+	lower := strings.ToLower(field)
+	rest, ok := strings.CutPrefix(lower, "https://")
+	if !ok {
+		rest, ok = strings.CutPrefix(lower, "http://")
+	}
+	if !ok {
+		if !strings.HasPrefix(lower, "www.") {
+			return "", false
+		}
+		rest = lower
+	}
+	host, _, _ := strings.Cut(rest, "/")
+	host = strings.TrimPrefix(host, "www.")
+	return strings.TrimRight(host, ".,;:!?)"), true
+}
+
+// linkDensity is the share of whitespace-separated words that are links.
+func linkDensity(text string) (links int, density float64) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return 0, 0
+	}
+	for _, field := range fields {
+		if _, ok := linkHost(field); ok {
+			links++
+		}
+	}
+	return links, float64(links) / float64(len(fields))
+}
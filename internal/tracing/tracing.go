@@ -0,0 +1,120 @@
+// Package tracing sets up OpenTelemetry tracing from the environment and
+// starts the spans the server records around requests and queries.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracer goes through the global provider, so spans started before
+// NewProvider runs, or with tracing off, cost nothing.
+var Tracer = otel.Tracer("github.com/pyculiar/chirpy")
+
+// NewProvider sets up the exporter named by OTEL_TRACES_EXPORTER: "otlp"
+// sends spans over OTLP/HTTP, configured by the standard
+// OTEL_EXPORTER_OTLP_* variables, and "file" writes them as JSON lines to
+// OTEL_TRACES_FILE, for testing without a collector. It returns a nil
+// provider when tracing is off, the default.
+//
+// The returned shutdown func flushes spans still queued and closes the
+// exporter; it's safe to call when tracing is off.
+func NewProvider(ctx context.Context) (*sdktrace.TracerProvider, func(context.Context) error, error) {
+	noop := func(context.Context) error { return nil }
+	var opt sdktrace.TracerProviderOption
+	closeExporter := noop
+	switch os.Getenv("OTEL_TRACES_EXPORTER") {
+	case "", "none":
+		return nil, noop, nil
+	case "otlp":
+		exporter, err := otlptracehttp.New(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		opt = sdktrace.WithBatcher(exporter)
+	case "file":
+		path := os.Getenv("OTEL_TRACES_FILE")
+		if path == "" {
+			return nil, nil, errors.New("OTEL_TRACES_FILE must be set for the file exporter")
+		}
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, nil, err
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(f))
+		if err != nil {
+			f.Close()
+			return nil, nil, err
+		}
+		// Written as each span ends, so nothing is lost if the server is
+		// killed rather than shut down.
+		opt = sdktrace.WithSyncer(exporter)
+		closeExporter = func(context.Context) error { return f.Close() }
+	default:
+		return nil, nil, errors.New("OTEL_TRACES_EXPORTER must be otlp, file or none")
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName("chirpy")),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		closeExporter(ctx)
+		return nil, nil, err
+	}
+	provider := sdktrace.NewTracerProvider(opt, sdktrace.WithResource(res))
+	shutdown := func(ctx context.Context) error {
+		return errors.Join(provider.Shutdown(ctx), closeExporter(ctx))
+	}
+	return provider, shutdown, nil
+}
+
+// StartServerSpan starts the span for a request to route, continuing the
+// trace from the client's traceparent header if it sent one.
+func StartServerSpan(r *http.Request, route string) (context.Context, trace.Span) {
+	ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+	return Tracer.Start(ctx, route,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(r.Method),
+			semconv.HTTPRoute(route),
+			semconv.URLPath(r.URL.Path),
+		),
+	)
+}
+
+// QueryName reads the name sqlc puts at the top of each query.
+func QueryName(query string) string {
+	name, ok := strings.CutPrefix(query, "-- name: ")
+	if !ok {
+		return "query"
+	}
+	name, _, _ = strings.Cut(name, " ")
+	return name
+}
+
+// StartQuerySpan starts a span for query, named after it.
+func StartQuerySpan(ctx context.Context, query string) (context.Context, trace.Span) {
+	name := QueryName(query)
+	return Tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBOperationName(name),
+			semconv.DBQueryText(query),
+		),
+	)
+}
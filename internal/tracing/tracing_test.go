@@ -0,0 +1,145 @@
+package tracing
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestQueryName(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"one", "-- name: GetChirpByID :one\nSELECT 1", "GetChirpByID"},
+		{"exec", "-- name: DeleteFollow :execrows\nWITH deleted AS (", "DeleteFollow"},
+		{"no name", "SELECT 1", "query"},
+		{"name not first", "SELECT 1 -- name: Sneaky :one", "query"},
+		{"empty", "", "query"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := QueryName(tt.query); got != tt.want {
+				t.Errorf("QueryName(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewProviderOff(t *testing.T) {
+	for _, exporter := range []string{"", "none"} {
+		t.Setenv("OTEL_TRACES_EXPORTER", exporter)
+		provider, shutdown, err := NewProvider(context.Background())
+		if err != nil || provider != nil {
+			t.Fatalf("NewProvider() with %q = %v, %v, want no provider", exporter, provider, err)
+		}
+		if err := shutdown(context.Background()); err != nil {
+			t.Errorf("shutdown() error = %v", err)
+		}
+	}
+}
+
+func TestNewProviderInvalid(t *testing.T) {
+	t.Setenv("OTEL_TRACES_EXPORTER", "jaeger")
+	if _, _, err := NewProvider(context.Background()); err == nil {
+		t.Error("NewProvider() accepted an unknown exporter")
+	}
+	t.Setenv("OTEL_TRACES_EXPORTER", "file")
+	t.Setenv("OTEL_TRACES_FILE", "")
+	if _, _, err := NewProvider(context.Background()); err == nil {
+		t.Error("NewProvider() accepted the file exporter without a file")
+	}
+}
+
+func TestNewProviderFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traces.jsonl")
+	t.Setenv("OTEL_TRACES_EXPORTER", "file")
+	t.Setenv("OTEL_TRACES_FILE", path)
+	provider, shutdown, err := NewProvider(context.Background())
+	if err != nil {
+		t.Fatalf("NewProvider() error = %v", err)
+	}
+	_, span := provider.Tracer("test").Start(context.Background(), "GetChirpByID")
+	span.End()
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown() error = %v", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Could not open traces: %v", err)
+	}
+	defer f.Close()
+	var names []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var exported struct {
+			Name     string
+			Resource []struct {
+				Key   string
+				Value struct{ Value any }
+			}
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &exported); err != nil {
+			t.Fatalf("Could not decode span %q: %v", scanner.Text(), err)
+		}
+		names = append(names, exported.Name)
+		service := ""
+		for _, attr := range exported.Resource {
+			if attr.Key == "service.name" {
+				service, _ = attr.Value.Value.(string)
+			}
+		}
+		if service != "chirpy" {
+			t.Errorf("span service.name = %q, want chirpy", service)
+		}
+	}
+	if len(names) != 1 || names[0] != "GetChirpByID" {
+		t.Errorf("exported spans = %v, want [GetChirpByID]", names)
+	}
+}
+
+func TestStartServerSpanContinuesTrace(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { provider.Shutdown(context.Background()) })
+
+	r := httptest.NewRequest("GET", "/api/chirps", nil)
+	r.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	_, span := StartServerSpan(r, "GET /api/chirps")
+	span.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("recorded %d spans, want 1", len(spans))
+	}
+	got := spans[0]
+	if got.Name() != "GET /api/chirps" {
+		t.Errorf("span name = %q", got.Name())
+	}
+	if traceID := got.SpanContext().TraceID().String(); traceID != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("trace ID = %s, want the client's", traceID)
+	}
+	if parent := got.Parent().SpanID().String(); parent != "00f067aa0ba902b7" || !got.Parent().IsRemote() {
+		t.Errorf("parent = %s (remote %v), want the client's span", parent, got.Parent().IsRemote())
+	}
+
+	// Without a traceparent the request starts its own trace.
+	_, span = StartServerSpan(httptest.NewRequest("GET", "/api/chirps", nil), "GET /api/chirps")
+	span.End()
+	if parent := recorder.Ended()[1].Parent(); parent.IsValid() {
+		t.Errorf("parent = %v, want a new trace", parent)
+	}
+}
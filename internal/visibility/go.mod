@@ -0,0 +1,3 @@
+module internal/visibility
+
+go 1.24.2
@@ -0,0 +1,32 @@
+// Package visibility names who can see a chirp. The rule itself lives in
+// the database, in can_view_chirp, so that every read path applies it; its
+// author and the users it mentions can always see a chirp, unless it's
+// hidden by a block or by moderation.
+package visibility
+
+import "errors"
+
+const (
+	// Public chirps can be seen by anyone.
+	Public = "public"
+	// Followers chirps can also be seen by the author's followers.
+	Followers = "followers"
+	// Mentioned chirps can only be seen by the author and the users they
+	// mention.
+	Mentioned = "mentioned"
+)
+
+// ErrInvalid is returned for a visibility that isn't one of the above.
+var ErrInvalid = errors.New("visibility must be public, followers or mentioned")
+
+// Parse checks a requested visibility, defaulting to Public.
+func Parse(visibility string) (string, error) {
+	switch visibility {
+	case "":
+		return Public, nil
+	case Public, Followers, Mentioned:
+		return visibility, nil
+	default:
+		return "", ErrInvalid
+	}
+}
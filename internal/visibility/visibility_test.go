@@ -0,0 +1,37 @@
+package visibility
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name       string
+		visibility string
+		want       string
+		wantErr    bool
+	}{
+		{"default", "", Public, false},
+		{"public", "public", Public, false},
+		{"followers", "followers", Followers, false},
+		{"mentioned", "mentioned", Mentioned, false},
+		{"unknown", "friends", "", true},
+		{"case sensitive", "Public", "", true},
+		{"surrounding whitespace", " public", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.visibility)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse(%q) error = %v, wantErr %v", tt.visibility, err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalid) {
+				t.Errorf("Parse(%q) error = %v, want ErrInvalid", tt.visibility, err)
+			}
+			if got != tt.want {
+				t.Errorf("Parse(%q) = %q, want %q", tt.visibility, got, tt.want)
+			}
+		})
+	}
+}
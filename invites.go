@@ -0,0 +1,433 @@
+package main
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/base32"
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"internal/database"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// Users earn an invite for every chirpsPerInvite chirps they post, up
+	// to maxEarnedInvites in all, once their account is minInviterAge old.
+	chirpsPerInvite  = 20
+	maxEarnedInvites = 10
+	minInviterAge    = 7 * 24 * time.Hour
+	// Invites users mint from their earned ones are single-use and expire
+	// after earnedInviteTTL.
+	earnedInviteTTL = 7 * 24 * time.Hour
+	maxInviteUses   = 1000
+)
+
+var errInvalidInvite = errors.New("invalid invite code")
+
+type Invite struct {
+	ID        uuid.UUID  `json:"id"`
+	Code      string     `json:"code"`
+	CreatedAt time.Time  `json:"created_at"`
+	CreatedBy uuid.UUID  `json:"created_by"`
+	MaxUses   int32      `json:"max_uses"`
+	Uses      int32      `json:"uses"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+type InvitePage struct {
+	Invites    []Invite `json:"invites"`
+	NextCursor string   `json:"next_cursor,omitempty"`
+}
+
+type MyInvites struct {
+	Available int      `json:"available"`
+	Invites   []Invite `json:"invites"`
+}
+
+type Invitee struct {
+	ID        uuid.UUID  `json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	Email     string     `json:"email"`
+	InvitedBy uuid.UUID  `json:"invited_by"`
+	Depth     int32      `json:"depth"`
+	BannedAt  *time.Time `json:"banned_at,omitempty"`
+}
+
+func newInvite(dbInvite database.Invite) Invite {
+	invite := Invite{
+		ID:        dbInvite.ID,
+		Code:      dbInvite.Code,
+		CreatedAt: dbInvite.CreatedAt,
+		CreatedBy: dbInvite.CreatedBy,
+		MaxUses:   dbInvite.MaxUses,
+		Uses:      dbInvite.Uses,
+	}
+	if dbInvite.ExpiresAt.Valid {
+		invite.ExpiresAt = &dbInvite.ExpiresAt.Time
+	}
+	if dbInvite.RevokedAt.Valid {
+		invite.RevokedAt = &dbInvite.RevokedAt.Time
+	}
+	return invite
+}
+
+// makeInviteCode returns a random code that's easy to read out and type.
+func makeInviteCode() (string, error) {
+	b := make([]byte, 10)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base32.StdEncoding.EncodeToString(b), nil
+}
+
+func normalizeInviteCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// redeemInvite uses up one use of code through q and returns who minted
+// it, or errInvalidInvite if the code can't be used.
+func redeemInvite(r *http.Request, q *database.Queries, code string) (uuid.UUID, error) {
+	invite, err := q.UseInvite(r.Context(), database.UseInviteParams{
+		Code: code,
+		Now:  time.Now(),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return uuid.Nil, errInvalidInvite
+	}
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return invite.CreatedBy, nil
+}
+
+// earnedInvites is how many invites user has earned so far, given how many
+// chirps they've posted.
+func earnedInvites(user database.User, chirps int64) int {
+	if accountRestriction(user) != "" || time.Since(user.CreatedAt) < minInviterAge {
+		return 0
+	}
+	return min(int(chirps/chirpsPerInvite), maxEarnedInvites)
+}
+
+// availableInvites is how many more invites the user can mint, read
+// through q.
+func availableInvites(r *http.Request, q *database.Queries, user database.User) (int, error) {
+	chirps, err := q.CountChirpsByUser(r.Context(), user.ID)
+	if err != nil {
+		return 0, err
+	}
+	minted, err := q.CountInvitesByCreator(r.Context(), user.ID)
+	if err != nil {
+		return 0, err
+	}
+	return max(earnedInvites(user, chirps)-int(minted), 0), nil
+}
+
+// handlerCreateInvite mints a single-use invite from the ones the user has
+// earned.
+func (cfg *apiConfig) handlerCreateInvite(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized access", nil)
+		return
+	}
+	code, err := makeInviteCode()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create invite", nil)
+		return
+	}
+
+	var dbInvite database.Invite
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		// Held until commit, so two requests can't both spend the last
+		// invite.
+		if err := q.LockUser(r.Context(), userID); err != nil {
+			return err
+		}
+		user, err := q.GetUserByID(r.Context(), userID)
+		if err != nil {
+			return err
+		}
+		available, err := availableInvites(r, q, user)
+		if err != nil {
+			return err
+		}
+		if available == 0 {
+			return errInvalidInvite
+		}
+		dbInvite, err = q.CreateInvite(r.Context(), database.CreateInviteParams{
+			Code:      code,
+			CreatedBy: userID,
+			MaxUses:   1,
+			ExpiresAt: sql.NullTime{Time: time.Now().Add(earnedInviteTTL), Valid: true},
+		})
+		return err
+	})
+	if errors.Is(err, errInvalidInvite) {
+		respondWithError(w, http.StatusForbidden, "You don't have any invites left", nil)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not create invite in DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create invite", nil)
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, newInvite(dbInvite))
+}
+
+// handlerGetMyInvites lists the invites the user has minted and how many
+// more they can.
+func (cfg *apiConfig) handlerGetMyInvites(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized access", nil)
+		return
+	}
+	user, err := cfg.db.GetUserByID(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized access", nil)
+		return
+	}
+
+	available, err := availableInvites(r, cfg.db, user)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not count invites in DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve invites", nil)
+		return
+	}
+	dbInvites, err := cfg.db.GetInvitesByCreator(r.Context(), userID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not retrieve invites from DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve invites", nil)
+		return
+	}
+	resp := MyInvites{Available: available, Invites: []Invite{}}
+	for _, dbInvite := range dbInvites {
+		resp.Invites = append(resp.Invites, newInvite(dbInvite))
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+// handlerMintInvite lets an admin create a code with any number of uses
+// and an optional expiry.
+func (cfg *apiConfig) handlerMintInvite(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		MaxUses        int32 `json:"max_uses"`
+		ExpiresInHours int   `json:"expires_in_hours"`
+	}
+	adminID, err := cfg.authenticatedStaff(r, roleAdmin)
+	if errors.Is(err, errNotStaff) {
+		respondWithError(w, http.StatusForbidden, "Admins only", nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized access", nil)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", nil)
+		return
+	}
+	if params.MaxUses == 0 {
+		params.MaxUses = 1
+	}
+	if params.MaxUses < 0 || params.MaxUses > maxInviteUses {
+		respondWithError(w, http.StatusBadRequest, "Max uses must be between 1 and 1000", nil)
+		return
+	}
+	if params.ExpiresInHours < 0 {
+		respondWithError(w, http.StatusBadRequest, "Expiry must be in the future", nil)
+		return
+	}
+	expiresAt := sql.NullTime{}
+	if params.ExpiresInHours > 0 {
+		expiresAt = sql.NullTime{Time: time.Now().Add(time.Duration(params.ExpiresInHours) * time.Hour), Valid: true}
+	}
+	code, err := makeInviteCode()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create invite", nil)
+		return
+	}
+
+	dbInvite, err := cfg.db.CreateInvite(r.Context(), database.CreateInviteParams{
+		Code:      code,
+		CreatedBy: adminID,
+		MaxUses:   params.MaxUses,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not create invite in DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create invite", nil)
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, newInvite(dbInvite))
+}
+
+// handlerGetInvites lists every invite, newest first.
+func (cfg *apiConfig) handlerGetInvites(w http.ResponseWriter, r *http.Request) {
+	_, err := cfg.authenticatedStaff(r, roleAdmin)
+	if errors.Is(err, errNotStaff) {
+		respondWithError(w, http.StatusForbidden, "Admins only", nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized access", nil)
+		return
+	}
+	p, err := parsePage(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	dbInvites, err := cfg.db.GetInvites(r.Context(), database.GetInvitesParams{
+		CursorCreatedAt: p.cursorCreatedAt,
+		CursorID:        p.cursorID,
+		PageSize:        p.size,
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not retrieve invites from DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve invites", nil)
+		return
+	}
+	resp := InvitePage{Invites: []Invite{}}
+	for _, dbInvite := range dbInvites {
+		resp.Invites = append(resp.Invites, newInvite(dbInvite))
+	}
+	if len(dbInvites) > 0 {
+		last := dbInvites[len(dbInvites)-1]
+		resp.NextCursor = p.nextCursor(len(dbInvites), last.CreatedAt, last.ID)
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+func (cfg *apiConfig) handlerRevokeInvite(w http.ResponseWriter, r *http.Request) {
+	_, err := cfg.authenticatedStaff(r, roleAdmin)
+	if errors.Is(err, errNotStaff) {
+		respondWithError(w, http.StatusForbidden, "Admins only", nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized access", nil)
+		return
+	}
+
+	revoked, err := cfg.db.RevokeInvite(r.Context(), normalizeInviteCode(r.PathValue("code")))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not revoke invite in DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't revoke invite", nil)
+		return
+	}
+	if revoked == 0 {
+		respondWithError(w, http.StatusNotFound, "Couldn't find invite", nil)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerGetInvitees lists every account the user invited, directly or
+// through the people they invited, nearest first.
+func (cfg *apiConfig) handlerGetInvitees(w http.ResponseWriter, r *http.Request) {
+	_, targetID, ok := cfg.staffTarget(w, r, roleModerator)
+	if !ok {
+		return
+	}
+
+	rows, err := cfg.db.GetInviteTree(r.Context(), targetID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not retrieve invite tree from DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve invitees", nil)
+		return
+	}
+	resp := []Invitee{}
+	for _, row := range rows {
+		invitee := Invitee{
+			ID:        row.ID,
+			CreatedAt: row.CreatedAt,
+			Email:     row.Email,
+			InvitedBy: row.InvitedBy.UUID,
+			Depth:     row.Depth,
+		}
+		if row.BannedAt.Valid {
+			invitee.BannedAt = &row.BannedAt.Time
+		}
+		resp = append(resp, invitee)
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+// handlerBanInvitees bans every account downstream of the user, and the
+// user too if asked, and revokes any invites they still hold.
+func (cfg *apiConfig) handlerBanInvitees(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		IncludeInviter bool   `json:"include_inviter"`
+		Note           string `json:"note"`
+	}
+	type response struct {
+		Banned []uuid.UUID `json:"banned"`
+	}
+	adminID, targetID, ok := cfg.staffTarget(w, r, roleAdmin)
+	if !ok {
+		return
+	}
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", nil)
+		return
+	}
+
+	var banned []uuid.UUID
+	err := cfg.withTx(r.Context(), func(q *database.Queries) error {
+		var err error
+		banned, err = q.BanInviteTree(r.Context(), targetID)
+		if err != nil {
+			return err
+		}
+		if params.IncludeInviter {
+			if err := q.BanUser(r.Context(), targetID); err != nil {
+				return err
+			}
+			banned = append(banned, targetID)
+		}
+		err = q.RevokeInvitesByCreators(r.Context(), append([]uuid.UUID{targetID}, banned...))
+		if err != nil {
+			return err
+		}
+		if err := q.RevokeRefreshTokensByUsers(r.Context(), banned); err != nil {
+			return err
+		}
+		for _, userID := range banned {
+			_, err := q.CreateModerationLogEntry(r.Context(), database.CreateModerationLogEntryParams{
+				ModeratorID: adminID,
+				Action:      logBan,
+				UserID:      uuid.NullUUID{UUID: userID, Valid: true},
+				Note:        params.Note,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not ban invitees in DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't ban invitees", nil)
+		return
+	}
+	for _, userID := range banned {
+		cfg.revokeSessions(r.Context(), userID)
+	}
+	if banned == nil {
+		banned = []uuid.UUID{}
+	}
+	respondWithJSON(w, http.StatusOK, response{Banned: banned})
+}
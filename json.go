@@ -2,16 +2,15 @@ package main
 
 import (
 	"encoding/json"
-	"log"
+	"internal/content"
+	"log/slog"
 	"net/http"
 )
 
 func respondWithError(w http.ResponseWriter, code int, msg string, err error) {
-	if err != nil {
-		log.Println(err)
-	}
-	if code > 499 {
-		log.Printf("Responding with 5XX error: %s", msg)
+	// The access log line for the request carries the error.
+	if rec, ok := w.(*responseRecorder); ok && err != nil {
+		rec.err = err
 	}
 	type errorResponse struct {
 		Error string `json:"error"`
@@ -21,11 +20,26 @@ func respondWithError(w http.ResponseWriter, code int, msg string, err error) {
 	})
 }
 
+// respondWithLengthError reports text over its length limit along with the
+// measured length, so clients can show how much needs cutting.
+func respondWithLengthError(w http.ResponseWriter, msg string, tooLong *content.LengthError) {
+	type lengthErrorResponse struct {
+		Error  string `json:"error"`
+		Length int    `json:"length"`
+		Limit  int    `json:"limit"`
+	}
+	respondWithJSON(w, http.StatusBadRequest, lengthErrorResponse{
+		Error:  msg,
+		Length: tooLong.Length,
+		Limit:  tooLong.Limit,
+	})
+}
+
 func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	dat, err := json.Marshal(payload)
 	if err != nil {
-		log.Printf("Error marshalling JSON: %v", err)
+		slog.Error("Could not marshal JSON", "err", err)
 		w.WriteHeader(500)
 		return
 	}
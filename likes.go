@@ -0,0 +1,123 @@
+package main
+
+import (
+	"github.com/google/uuid"
+	"internal/database"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"time"
+)
+
+// likeCounterShards is how many rows each chirp's like count is spread over.
+const likeCounterShards = 16
+
+type Like struct {
+	UserID  uuid.UUID `json:"user_id"`
+	LikedAt time.Time `json:"liked_at"`
+}
+
+type LikePage struct {
+	Users      []Like `json:"users"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+func (cfg *apiConfig) handlerLikeChirp(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized access", nil)
+		return
+	}
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", nil)
+		return
+	}
+	chirp, err := cfg.db.GetChirpByID(r.Context(), database.GetChirpByIDParams{
+		ID:       chirpID,
+		ViewerID: userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Couldn't retrieve chirp", nil)
+		return
+	}
+
+	created, err := cfg.db.CreateLike(r.Context(), database.CreateLikeParams{
+		ChirpID: chirpID,
+		UserID:  userID,
+		Shard:   int16(rand.IntN(likeCounterShards)),
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not create like in DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't like chirp", nil)
+		return
+	}
+	if created > 0 {
+		cfg.notify(r.Context(), chirp.UserID, notificationLike, userID, uuid.NullUUID{UUID: chirp.ID, Valid: true})
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerUnlikeChirp(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized access", nil)
+		return
+	}
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", nil)
+		return
+	}
+
+	_, err = cfg.db.DeleteLike(r.Context(), database.DeleteLikeParams{
+		ChirpID: chirpID,
+		UserID:  userID,
+		Shard:   int16(rand.IntN(likeCounterShards)),
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not delete like in DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't unlike chirp", nil)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerGetLikers(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", nil)
+		return
+	}
+	p, err := parsePage(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	if !cfg.canView(r.Context(), chirpID, cfg.viewerID(r)) {
+		respondWithError(w, http.StatusNotFound, "Couldn't retrieve chirp", nil)
+		return
+	}
+
+	rows, err := cfg.db.GetLikers(r.Context(), database.GetLikersParams{
+		ChirpID:         chirpID,
+		CursorCreatedAt: p.cursorCreatedAt,
+		CursorID:        p.cursorID,
+		PageSize:        p.size,
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not retrieve likers from DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve likes", nil)
+		return
+	}
+
+	resp := LikePage{Users: []Like{}}
+	for _, row := range rows {
+		resp.Users = append(resp.Users, Like{UserID: row.UserID, LikedAt: row.CreatedAt})
+	}
+	if len(rows) > 0 {
+		last := rows[len(rows)-1]
+		resp.NextCursor = p.nextCursor(len(rows), last.CreatedAt, last.UserID)
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"go.opentelemetry.io/otel/trace"
+	"internal/logging"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+)
+
+// maxRequestIDLength bounds the X-Request-ID accepted from clients and
+// proxies; longer or unprintable IDs are replaced.
+const maxRequestIDLength = 128
+
+// newLogger writes JSON lines to stderr at LOG_LEVEL, info by default.
+func newLogger() (*slog.Logger, error) {
+	var level slog.Level
+	if value := os.Getenv("LOG_LEVEL"); value != "" {
+		if err := level.UnmarshalText([]byte(value)); err != nil {
+			return nil, err
+		}
+	}
+	return logging.New(os.Stderr, level), nil
+}
+
+// exitWithError logs a startup failure and exits.
+func exitWithError(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		if c < '!' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// middlewareRequestLog tags each request with an ID, taken from
+// X-Request-ID if the client or a proxy sent one, and logs an access line
+// once it's been served. Errors handed to respondWithError are logged on
+// that line.
+func (cfg *apiConfig) middlewareRequestLog(mux *http.ServeMux, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("X-Request-ID")
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		w.Header().Set("X-Request-ID", id)
+		ctx := logging.WithRequestID(r.Context(), id)
+		r = r.WithContext(ctx)
+
+		rec := recordResponse(w)
+		start := time.Now()
+		next.ServeHTTP(rec, r)
+
+		_, route := mux.Handler(r)
+		if route == "" {
+			route = unmatchedRoute
+		}
+		attrs := []slog.Attr{
+			slog.String("method", r.Method),
+			slog.String("route", route),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", rec.statusCode()),
+			slog.Int("bytes", rec.size),
+			slog.Duration("duration", time.Since(start)),
+			slog.String("remote_ip", cfg.clientIP(r)),
+		}
+		level := slog.LevelInfo
+		if rec.statusCode() > 499 {
+			level = slog.LevelError
+		}
+		if rec.err != nil {
+			attrs = append(attrs, slog.Any("err", rec.err))
+		}
+		if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+			attrs = append(attrs, slog.String("trace_id", sc.TraceID().String()))
+		}
+		slog.LogAttrs(ctx, level, "Served request", attrs...)
+	})
+}
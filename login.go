@@ -4,7 +4,8 @@ import (
 	"encoding/json"
 	"github.com/google/uuid"
 	"internal/auth"
-	"log"
+	"internal/database"
+	"log/slog"
 	"net/http"
 	"time"
 )
@@ -34,14 +35,20 @@ func (cfg *apiConfig) handlerLogin(w http.ResponseWriter, r *http.Request) {
 
 	user, err := cfg.db.GetUser(r.Context(), params.Email)
 	if err != nil {
-		log.Printf("Could not get user %s from DB: %v", params.Email, err)
+		slog.InfoContext(r.Context(), "Could not get user from DB", "err", err)
+		cfg.metrics.loginsFailed.WithLabelValues("unknown_user").Inc()
 		respondWithError(w, http.StatusUnauthorized, "Incorrect email or password", nil)
 		return
 	}
 
-	if auth.CheckPasswordHash(user.HashedPassword, params.Password) != nil {
+	if checkPasswordHash(r.Context(), user.HashedPassword, params.Password) != nil {
+		cfg.metrics.loginsFailed.WithLabelValues("bad_password").Inc()
 		respondWithError(w, http.StatusUnauthorized, "Incorrect email or password", nil)
 		return
+	} else if restricted := accountRestriction(user); restricted != "" {
+		cfg.metrics.loginsFailed.WithLabelValues("restricted").Inc()
+		respondWithError(w, http.StatusForbidden, restricted, nil)
+		return
 	} else {
 		token, err := auth.MakeJWT(user.ID, cfg.secretToken)
 		if err != nil {
@@ -53,6 +60,15 @@ func (cfg *apiConfig) handlerLogin(w http.ResponseWriter, r *http.Request) {
 			respondWithError(w, http.StatusInternalServerError, "Something went wrong", nil)
 			return
 		}
+		_, err = cfg.db.CreateRefreshToken(r.Context(), database.CreateRefreshTokenParams{
+			Token:  refreshToken,
+			UserID: user.ID,
+		})
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Could not store refresh token in DB", "err", err)
+			respondWithError(w, http.StatusInternalServerError, "Something went wrong", nil)
+			return
+		}
 		respondWithJSON(w, http.StatusOK, User{
 			ID:           user.ID,
 			CreatedAt:    user.CreatedAt,
@@ -2,18 +2,44 @@ package main
 
 import _ "github.com/lib/pq"
 import (
+	"context"
 	"database/sql"
 	"github.com/joho/godotenv"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"internal/blobstore"
 	"internal/database"
+	"internal/moderation"
+	"internal/ratelimit"
+	"internal/spam"
+	"internal/tracing"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
 	"sync/atomic"
+	"syscall"
+	"time"
 )
 
 type apiConfig struct {
 	db             *database.Queries
-	fileserverHits atomic.Int32
+	dbConn         *sql.DB
+	metrics        *metrics
+	metricsToken   string
+	realtime       *realtimeHub
+	gateway        *gatewayHub
+	media          blobstore.BlobStore
+	wordFilter     atomic.Pointer[moderation.Filter]
+	spamScorer     spam.Scorer
+	spamThresholds spam.Thresholds
+	signupPowBits  int
+	signupPowKey   []byte
+	inviteOnly     bool
+	rateLimiter    ratelimit.Store
+	rateLimits     map[string]ratelimit.Rule
+	trustProxy     bool
 	platform       string
 	secretToken    string
 }
@@ -21,54 +47,219 @@ type apiConfig struct {
 func main() {
 	const filepathRoot = "."
 	const port = "8080"
+	const shutdownTimeout = 30 * time.Second
 
 	godotenv.Load()
+	logger, err := newLogger()
+	if err != nil {
+		log.Fatalf("Invalid LOG_LEVEL: %s", err)
+	}
+	slog.SetDefault(logger)
+
+	tracerProvider, shutdownTracing, err := tracing.NewProvider(context.Background())
+	if err != nil {
+		exitWithError("Cannot set up tracing", "err", err)
+	}
+	if tracerProvider != nil {
+		otel.SetTracerProvider(tracerProvider)
+	}
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
 	secretToken := os.Getenv("SECRET_TOKEN")
 	if secretToken == "" {
-		log.Fatal("SECRET_TOKEN must be set")
+		exitWithError("SECRET_TOKEN must be set")
 	}
 
 	dbURL := os.Getenv("DB_URL")
 	if dbURL == "" {
-		log.Fatal("DB_URL must be set")
+		exitWithError("DB_URL must be set")
 	}
 
 	platform := os.Getenv("PLATFORM")
 	if platform == "" {
-		log.Fatal("PLATFORM must be set")
+		exitWithError("PLATFORM must be set")
+	}
+
+	powBits, err := signupPowBits()
+	if err != nil {
+		exitWithError("Invalid signup challenge difficulty", "err", err)
+	}
+	powKey, err := signupPowKey(secretToken)
+	if err != nil {
+		exitWithError("Cannot derive signup challenge key", "err", err)
+	}
+
+	rateLimits, err := rateLimitRules()
+	if err != nil {
+		exitWithError("Invalid rate limits", "err", err)
+	}
+
+	media, err := newBlobStore()
+	if err != nil {
+		exitWithError("Cannot open media store", "err", err)
 	}
 
 	dbConn, err := sql.Open("postgres", dbURL)
 	if err != nil {
-		log.Fatalf("Cannot open database: %s", err)
+		exitWithError("Cannot open database", "err", err)
 	}
 
-	dbQueries := database.New(dbConn)
+	dbQueries := database.New(tracedDB{dbConn})
 
 	mux := http.NewServeMux()
 	apiCfg := apiConfig{
 		db:             dbQueries,
-		fileserverHits: atomic.Int32{},
+		dbConn:         dbConn,
+		metrics:        newMetrics(dbConn),
+		metricsToken:   os.Getenv("METRICS_TOKEN"),
 		platform:       platform,
 		secretToken:    secretToken,
+		realtime:       newRealtimeHub(),
+		gateway:        newGatewayHub(),
+		media:          media,
+		spamScorer:     loadSpamScorer(),
+		spamThresholds: spam.DefaultThresholds,
+		signupPowBits:  powBits,
+		signupPowKey:   powKey,
+		inviteOnly:     os.Getenv("INVITE_ONLY") == "true",
+		rateLimits:     rateLimits,
+		trustProxy:     os.Getenv("RATE_LIMIT_TRUST_PROXY") == "true",
+	}
+	apiCfg.rateLimiter, err = newRateLimiter(&apiCfg)
+	if err != nil {
+		exitWithError("Invalid rate limit store", "err", err)
+	}
+	go apiCfg.realtime.listen(dbURL)
+	go apiCfg.dispatchGatewayChirps(context.Background())
+	go apiCfg.runScheduler(context.Background())
+	if err := apiCfg.reloadModerationWords(context.Background()); err != nil {
+		slog.Error("Could not load moderation words", "err", err)
 	}
+	go apiCfg.watchModerationWords(context.Background())
 
-	mux.Handle("/app/", apiCfg.middlewareMetricsInc(http.StripPrefix("/app", http.FileServer(http.Dir(filepathRoot)))))
-	mux.HandleFunc("GET /admin/metrics", apiCfg.handlerMetrics)
+	mux.Handle("/app/", http.StripPrefix("/app", http.FileServer(http.Dir(filepathRoot))))
 	mux.HandleFunc("POST /admin/reset", apiCfg.handlerReset)
+	mux.HandleFunc("GET /admin/moderation/words", apiCfg.handlerGetModerationWords)
+	mux.HandleFunc("PUT /admin/moderation/words", apiCfg.handlerPutModerationWord)
+	mux.HandleFunc("DELETE /admin/moderation/words/{word}", apiCfg.handlerDeleteModerationWord)
+	mux.HandleFunc("GET /admin/moderation/flags", apiCfg.handlerGetFlaggedChirps)
+	mux.HandleFunc("GET /admin/moderation/log", apiCfg.handlerGetModerationLog)
+	mux.HandleFunc("POST /admin/api_keys", apiCfg.handlerCreateAPIKey)
+	mux.HandleFunc("DELETE /admin/api_keys/{keyID}", apiCfg.handlerRevokeAPIKey)
+	mux.HandleFunc("GET /admin/invites", apiCfg.handlerGetInvites)
+	mux.HandleFunc("POST /admin/invites", apiCfg.handlerMintInvite)
+	mux.HandleFunc("DELETE /admin/invites/{code}", apiCfg.handlerRevokeInvite)
+	mux.HandleFunc("GET /admin/reports", apiCfg.handlerGetReports)
+	mux.HandleFunc("POST /admin/reports/{reportID}/claim", apiCfg.handlerClaimReport)
+	mux.HandleFunc("POST /admin/reports/{reportID}/resolve", apiCfg.handlerResolveReport)
+	mux.HandleFunc("POST /admin/reports/{reportID}/dismiss", apiCfg.handlerDismissReport)
+	mux.HandleFunc("GET /admin/shadow_bans", apiCfg.handlerGetShadowBans)
+	mux.HandleFunc("GET /admin/spam/held", apiCfg.handlerGetHeldChirps)
+	mux.HandleFunc("POST /admin/spam/held/{chirpID}/release", apiCfg.handlerReleaseHeldChirp)
+	mux.HandleFunc("POST /admin/spam/held/{chirpID}/reject", apiCfg.handlerRejectHeldChirp)
+	mux.HandleFunc("PUT /admin/users/{userID}/shadow_ban", apiCfg.handlerShadowBanUser)
+	mux.HandleFunc("DELETE /admin/users/{userID}/shadow_ban", apiCfg.handlerLiftShadowBan)
+	mux.HandleFunc("GET /admin/users/{userID}/invitees", apiCfg.handlerGetInvitees)
+	mux.HandleFunc("POST /admin/users/{userID}/invitees/ban", apiCfg.handlerBanInvitees)
 	mux.HandleFunc("GET /api/healthz", handlerReadiness)
+	mux.HandleFunc("GET /metrics", apiCfg.handlerMetrics)
 	mux.HandleFunc("POST /api/users", apiCfg.handlerCreateUser)
+	mux.HandleFunc("GET /api/users/challenge", apiCfg.handlerSignupChallenge)
+	mux.HandleFunc("POST /api/invites", apiCfg.handlerCreateInvite)
+	mux.HandleFunc("GET /api/invites", apiCfg.handlerGetMyInvites)
 	mux.HandleFunc("POST /api/chirps", apiCfg.handlerCreateChirp)
 	mux.HandleFunc("GET /api/chirps", apiCfg.handlerGetChirps)
 	mux.HandleFunc("GET /api/chirps/{chirpID}", apiCfg.handlerGetChirpByID)
+	mux.HandleFunc("GET /api/chirps/{chirpID}/thread", apiCfg.handlerGetThread)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/like", apiCfg.handlerLikeChirp)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/like", apiCfg.handlerUnlikeChirp)
+	mux.HandleFunc("GET /api/chirps/{chirpID}/likes", apiCfg.handlerGetLikers)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}", apiCfg.handlerDeleteChirp)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/rechirp", apiCfg.handlerRechirp)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/rechirp", apiCfg.handlerUndoRechirp)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/poll/vote", apiCfg.handlerVotePoll)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/bookmark", apiCfg.handlerBookmarkChirp)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/bookmark", apiCfg.handlerRemoveBookmark)
+	mux.HandleFunc("GET /api/bookmarks", apiCfg.handlerGetBookmarks)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/pin", apiCfg.handlerPinChirp)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/pin", apiCfg.handlerUnpinChirp)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/report", apiCfg.handlerReportChirp)
 	mux.HandleFunc("POST /api/login", apiCfg.handlerLogin)
 	mux.HandleFunc("POST /api/refresh", apiCfg.handlerRefresh)
+	mux.HandleFunc("POST /api/revoke", apiCfg.handlerRevoke)
+	mux.HandleFunc("POST /api/users/{userID}/follow", apiCfg.handlerFollowUser)
+	mux.HandleFunc("DELETE /api/users/{userID}/follow", apiCfg.handlerUnfollowUser)
+	mux.HandleFunc("GET /api/users/{userID}/followers", apiCfg.handlerGetFollowers)
+	mux.HandleFunc("GET /api/users/{userID}/following", apiCfg.handlerGetFollowing)
+	mux.HandleFunc("GET /api/users/{userID}/chirps", apiCfg.handlerGetChirpsByAuthor)
+	mux.HandleFunc("POST /api/users/{userID}/block", apiCfg.handlerBlockUser)
+	mux.HandleFunc("DELETE /api/users/{userID}/block", apiCfg.handlerUnblockUser)
+	mux.HandleFunc("POST /api/users/{userID}/mute", apiCfg.handlerMuteUser)
+	mux.HandleFunc("DELETE /api/users/{userID}/mute", apiCfg.handlerUnmuteUser)
+	mux.HandleFunc("POST /api/users/{userID}/report", apiCfg.handlerReportUser)
+	mux.HandleFunc("GET /api/timeline/home", apiCfg.handlerHomeTimeline)
+	mux.HandleFunc("GET /api/stream/chirps", apiCfg.handlerStreamChirps)
+	mux.HandleFunc("GET /api/ws", apiCfg.handlerGateway)
+	mux.HandleFunc("GET /api/notifications", apiCfg.handlerGetNotifications)
+	mux.HandleFunc("GET /api/notifications/unread_count", apiCfg.handlerUnreadNotificationCount)
+	mux.HandleFunc("POST /api/notifications/read", apiCfg.handlerMarkAllNotificationsRead)
+	mux.HandleFunc("POST /api/notifications/{notificationID}/read", apiCfg.handlerMarkNotificationRead)
+	mux.HandleFunc("POST /api/drafts", apiCfg.handlerCreateDraft)
+	mux.HandleFunc("GET /api/drafts", apiCfg.handlerGetDrafts)
+	mux.HandleFunc("GET /api/drafts/{draftID}", apiCfg.handlerGetDraft)
+	mux.HandleFunc("PUT /api/drafts/{draftID}", apiCfg.handlerUpdateDraft)
+	mux.HandleFunc("DELETE /api/drafts/{draftID}", apiCfg.handlerDeleteDraft)
+	mux.HandleFunc("POST /api/drafts/{draftID}/publish", apiCfg.handlerPublishDraft)
+	mux.HandleFunc("POST /api/media", apiCfg.handlerUploadMedia)
+	mux.HandleFunc("GET /api/media/{mediaID}", apiCfg.handlerGetMedia)
+	mux.HandleFunc("GET /api/media/{mediaID}/thumbnail", apiCfg.handlerGetMediaThumbnail)
+	mux.HandleFunc("POST /api/conversations", apiCfg.handlerCreateConversation)
+	mux.HandleFunc("GET /api/conversations", apiCfg.handlerGetConversations)
+	mux.HandleFunc("GET /api/conversations/{conversationID}", apiCfg.handlerGetConversation)
+	mux.HandleFunc("GET /api/conversations/{conversationID}/messages", apiCfg.handlerGetDirectMessages)
+	mux.HandleFunc("POST /api/conversations/{conversationID}/messages", apiCfg.handlerSendDirectMessage)
+	mux.HandleFunc("POST /api/conversations/{conversationID}/read", apiCfg.handlerMarkConversationRead)
 
+	var handler http.Handler = mux
+	if apiCfg.rateLimiter != nil {
+		handler = apiCfg.middlewareRateLimit(mux)
+	}
+	handler = apiCfg.middlewareMetrics(mux, handler)
+	handler = apiCfg.middlewareRequestLog(mux, handler)
+	handler = apiCfg.middlewareTracing(mux, handler)
 	srv := &http.Server{
-		Addr:    ":" + port,
-		Handler: mux,
+		Addr:     ":" + port,
+		Handler:  handler,
+		ErrorLog: slog.NewLogLogger(logger.Handler(), slog.LevelError),
 	}
 
-	log.Printf("Serving files from %s on port %s\n", filepathRoot, port)
-	log.Fatal(srv.ListenAndServe())
+	// Stop on SIGINT or SIGTERM, letting requests in flight finish and
+	// flushing traces before exiting.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- srv.ListenAndServe()
+	}()
+	slog.Info("Serving files", "root", filepathRoot, "port", port)
+
+	failed := false
+	select {
+	case err := <-serveErr:
+		slog.Error("Server stopped", "err", err)
+		failed = true
+	case <-ctx.Done():
+		slog.Info("Shutting down")
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		slog.Error("Could not shut down server", "err", err)
+	}
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		slog.Error("Could not flush traces", "err", err)
+	}
+	cancel()
+	if failed {
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"internal/blobstore"
+	"internal/database"
+	"internal/images"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+)
+
+const (
+	maxMediaSize     = 5 << 20
+	maxChirpMedia    = 4
+	mediaCacheMaxAge = 365 * 24 * 60 * 60
+)
+
+// Media is an image attached to a chirp.
+type Media struct {
+	ID           uuid.UUID `json:"id"`
+	ContentType  string    `json:"content_type"`
+	Width        int32     `json:"width"`
+	Height       int32     `json:"height"`
+	URL          string    `json:"url"`
+	ThumbnailURL string    `json:"thumbnail_url"`
+}
+
+func newMedia(dbMedia database.Medium) Media {
+	return Media{
+		ID:           dbMedia.ID,
+		ContentType:  dbMedia.ContentType,
+		Width:        dbMedia.Width,
+		Height:       dbMedia.Height,
+		URL:          "/api/media/" + dbMedia.ID.String(),
+		ThumbnailURL: "/api/media/" + dbMedia.ID.String() + "/thumbnail",
+	}
+}
+
+// newBlobStore opens the store selected by MEDIA_STORE: "local" (the default)
+// keeps files under MEDIA_DIR, "s3" uses the bucket described by the S3_*
+// variables.
+func newBlobStore() (blobstore.BlobStore, error) {
+	switch os.Getenv("MEDIA_STORE") {
+	case "", "local":
+		dir := os.Getenv("MEDIA_DIR")
+		if dir == "" {
+			dir = "./uploads"
+		}
+		return blobstore.NewLocal(dir)
+	case "s3":
+		s3 := &blobstore.S3{
+			Endpoint:        os.Getenv("S3_ENDPOINT"),
+			Region:          os.Getenv("S3_REGION"),
+			Bucket:          os.Getenv("S3_BUCKET"),
+			AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+		}
+		if s3.Endpoint == "" || s3.Region == "" || s3.Bucket == "" {
+			return nil, errors.New("S3_ENDPOINT, S3_REGION and S3_BUCKET must be set")
+		}
+		return s3, nil
+	default:
+		return nil, errors.New("MEDIA_STORE must be local or s3")
+	}
+}
+
+func mediaExtension(contentType string) string {
+	if contentType == "image/png" {
+		return ".png"
+	}
+	return ".jpg"
+}
+
+// loadMedia attaches media to each chirp in one query.
+func (cfg *apiConfig) loadMedia(ctx context.Context, chirps []Chirp, ids []uuid.UUID) error {
+	dbMedia, err := cfg.db.GetMediaForChirps(ctx, ids)
+	if err != nil {
+		return err
+	}
+	media := map[uuid.UUID][]Media{}
+	for _, m := range dbMedia {
+		media[m.ChirpID.UUID] = append(media[m.ChirpID.UUID], newMedia(m))
+	}
+	for i := range chirps {
+		chirps[i].Media = media[chirps[i].ID]
+	}
+	return nil
+}
+
+// handlerUploadMedia accepts a multipart image upload in the "file" field.
+// The upload is stored unattached; it's attached by passing its ID in
+// media_ids when creating a chirp.
+func (cfg *apiConfig) handlerUploadMedia(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized access", nil)
+		return
+	}
+
+	// Leave room for the multipart framing around the file itself.
+	r.Body = http.MaxBytesReader(w, r.Body, maxMediaSize+64<<10)
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			respondWithError(w, http.StatusRequestEntityTooLarge, "File is too large", nil)
+			return
+		}
+		respondWithError(w, http.StatusBadRequest, "Couldn't read file", nil)
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, maxMediaSize+1))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't read file", nil)
+		return
+	}
+	if len(data) > maxMediaSize {
+		respondWithError(w, http.StatusRequestEntityTooLarge, "File is too large", nil)
+		return
+	}
+
+	// Trust the bytes, not the client's declared content type.
+	contentType := http.DetectContentType(data)
+	processed, err := images.Process(data, contentType)
+	if errors.Is(err, images.ErrUnsupported) {
+		respondWithError(w, http.StatusUnsupportedMediaType, "Only JPEG and PNG images are supported", nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	id := uuid.New()
+	ext := mediaExtension(processed.ContentType)
+	storageKey := "media/" + id.String() + ext
+	thumbnailKey := "media/" + id.String() + "_thumb" + ext
+	var stored []string
+	for key, blob := range map[string][]byte{storageKey: processed.Data, thumbnailKey: processed.Thumbnail} {
+		err := cfg.media.Put(r.Context(), key, bytes.NewReader(blob), int64(len(blob)), processed.ContentType)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Could not store media", "key", key, "err", err)
+			cfg.deleteMediaBlobs(r.Context(), stored)
+			respondWithError(w, http.StatusInternalServerError, "Couldn't store file", nil)
+			return
+		}
+		stored = append(stored, key)
+	}
+
+	dbMedia, err := cfg.db.CreateMedia(r.Context(), database.CreateMediaParams{
+		ID:           id,
+		UserID:       userID,
+		ContentType:  processed.ContentType,
+		Width:        int32(processed.Width),
+		Height:       int32(processed.Height),
+		SizeBytes:    int64(len(processed.Data)),
+		StorageKey:   storageKey,
+		ThumbnailKey: thumbnailKey,
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not create media in DB", "err", err)
+		cfg.deleteMediaBlobs(r.Context(), stored)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't store file", nil)
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, newMedia(dbMedia))
+}
+
+// deleteMediaBlobs removes blobs stored for an upload that then failed, so
+// they aren't left behind with no media row pointing at them.
+func (cfg *apiConfig) deleteMediaBlobs(ctx context.Context, keys []string) {
+	for _, key := range keys {
+		if err := cfg.media.Delete(ctx, key); err != nil {
+			slog.ErrorContext(ctx, "Could not delete media", "key", key, "err", err)
+		}
+	}
+}
+
+func (cfg *apiConfig) handlerGetMedia(w http.ResponseWriter, r *http.Request) {
+	cfg.serveMedia(w, r, false)
+}
+
+func (cfg *apiConfig) handlerGetMediaThumbnail(w http.ResponseWriter, r *http.Request) {
+	cfg.serveMedia(w, r, true)
+}
+
+// serveMedia streams a stored image to anyone who can see the chirp it's
+// attached to, or only to its uploader until it's attached. Blobs never
+// change once uploaded, so they're cached for a year and revalidated by ID
+// alone, but only in shared caches if anyone may see them.
+func (cfg *apiConfig) serveMedia(w http.ResponseWriter, r *http.Request, thumb bool) {
+	mediaID, err := uuid.Parse(r.PathValue("mediaID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid media ID", nil)
+		return
+	}
+	row, err := cfg.db.GetMedia(r.Context(), database.GetMediaParams{
+		ID:       mediaID,
+		ViewerID: cfg.viewerID(r),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Couldn't find media", nil)
+		return
+	}
+	dbMedia := row.Medium
+	key := dbMedia.StorageKey
+	etag := `"` + dbMedia.ID.String() + `"`
+	if thumb {
+		key = dbMedia.ThumbnailKey
+		etag = `"` + dbMedia.ID.String() + `-thumb"`
+	}
+
+	cacheControl := "private, max-age=" + strconv.Itoa(mediaCacheMaxAge) + ", immutable"
+	if row.Public {
+		cacheControl = "public, max-age=" + strconv.Itoa(mediaCacheMaxAge) + ", immutable"
+	}
+	if r.Header.Get("If-None-Match") == etag {
+		w.Header().Set("Cache-Control", cacheControl)
+		w.Header().Set("ETag", etag)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	blob, info, err := cfg.media.Get(r.Context(), key)
+	if errors.Is(err, blobstore.ErrNotFound) {
+		respondWithError(w, http.StatusNotFound, "Couldn't find media", nil)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not read media", "key", key, "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't read media", nil)
+		return
+	}
+	defer blob.Close()
+
+	w.Header().Set("Cache-Control", cacheControl)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Content-Type", dbMedia.ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if info.Size >= 0 {
+		w.Header().Set("Content-Length", strconv.FormatInt(info.Size, 10))
+	}
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, blob); err != nil {
+		slog.ErrorContext(r.Context(), "Could not send media", "key", key, "err", err)
+	}
+}
@@ -1,26 +1,160 @@
 package main
 
 import (
-	"fmt"
+	"bufio"
+	"crypto/subtle"
+	"database/sql"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"internal/auth"
+	"net"
 	"net/http"
+	"strconv"
+	"time"
 )
 
-func (cfg *apiConfig) middlewareMetricsInc(next http.Handler) http.Handler {
+// unmatchedRoute labels requests that no route matched, so stray paths
+// don't each get their own series.
+const unmatchedRoute = "unmatched"
+
+type metrics struct {
+	handler         http.Handler
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	responseSize    *prometheus.HistogramVec
+	chirpsCreated   prometheus.Counter
+	usersCreated    prometheus.Counter
+	loginsFailed    *prometheus.CounterVec
+}
+
+func newMetrics(db *sql.DB) *metrics {
+	registry := prometheus.NewRegistry()
+	m := &metrics{
+		handler: promhttp.HandlerFor(registry, promhttp.HandlerOpts{}),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "chirpy_http_requests_total",
+			Help: "HTTP requests by route and status class.",
+		}, []string{"route", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "chirpy_http_request_duration_seconds",
+			Help:    "Time to serve HTTP requests, by route.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"route"}),
+		responseSize: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "chirpy_http_response_size_bytes",
+			Help:    "Size of HTTP response bodies, by route.",
+			Buckets: prometheus.ExponentialBuckets(100, 10, 6),
+		}, []string{"route"}),
+		chirpsCreated: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "chirpy_chirps_created_total",
+			Help: "Chirps posted, directly or from drafts.",
+		}),
+		usersCreated: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "chirpy_users_created_total",
+			Help: "Accounts signed up.",
+		}),
+		loginsFailed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "chirpy_logins_failed_total",
+			Help: "Refused logins, by reason.",
+		}, []string{"reason"}),
+	}
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		collectors.NewDBStatsCollector(db, "chirpy"),
+		m.requests,
+		m.requestDuration,
+		m.responseSize,
+		m.chirpsCreated,
+		m.usersCreated,
+		m.loginsFailed,
+	)
+	return m
+}
+
+// responseRecorder notes the status and body size of a response, and the
+// error behind it if there was one. It passes Flush and Hijack through, so
+// streams and websockets still work behind it.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	size   int
+	err    error
+}
+
+// recordResponse returns w if it's already recording, so the middlewares
+// share one recorder, or wraps it in a new one.
+func recordResponse(w http.ResponseWriter) *responseRecorder {
+	if rec, ok := w.(*responseRecorder); ok {
+		return rec
+	}
+	return &responseRecorder{ResponseWriter: w}
+}
+
+func (rec *responseRecorder) statusCode() int {
+	if rec.status == 0 {
+		return http.StatusOK
+	}
+	return rec.status
+}
+
+func (rec *responseRecorder) WriteHeader(code int) {
+	if rec.status == 0 {
+		rec.status = code
+	}
+	rec.ResponseWriter.WriteHeader(code)
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	n, err := rec.ResponseWriter.Write(b)
+	rec.size += n
+	return n, err
+}
+
+func (rec *responseRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+func (rec *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, brw, err := http.NewResponseController(rec.ResponseWriter).Hijack()
+	if err == nil && rec.status == 0 {
+		rec.status = http.StatusSwitchingProtocols
+	}
+	return conn, brw, err
+}
+
+// middlewareMetrics records every request that reaches next under the
+// pattern mux routes it to.
+func (cfg *apiConfig) middlewareMetrics(mux *http.ServeMux, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		cfg.fileserverHits.Add(1)
-		next.ServeHTTP(w, r)
+		_, route := mux.Handler(r)
+		if route == "" {
+			route = unmatchedRoute
+		}
+		rec := recordResponse(w)
+		start := time.Now()
+		next.ServeHTTP(rec, r)
+
+		status := strconv.Itoa(rec.statusCode()/100) + "xx"
+		cfg.metrics.requests.WithLabelValues(route, status).Inc()
+		cfg.metrics.requestDuration.WithLabelValues(route).Observe(time.Since(start).Seconds())
+		cfg.metrics.responseSize.WithLabelValues(route).Observe(float64(rec.size))
 	})
 }
 
+// handlerMetrics serves metrics in the Prometheus exposition format. When
+// METRICS_TOKEN is set, scrapers must send it as a bearer token.
 func (cfg *apiConfig) handlerMetrics(w http.ResponseWriter, r *http.Request) {
-	w.Header().Add("Content-Type", "text/html")
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte(fmt.Sprintf(`
-	<html> 
-	<body> 
-	<h1>Welcome, Chirpy Admin</h1> 
-	<p>Chirpy has been visited %d times!</p> 
-	</body> 
-	</html>
-	`, cfg.fileserverHits.Load())))
+	if cfg.metricsToken != "" {
+		token, err := auth.GetBearerToken(r.Header)
+		if err != nil || subtle.ConstantTimeCompare([]byte(token), []byte(cfg.metricsToken)) != 1 {
+			respondWithError(w, http.StatusUnauthorized, "Unauthorized access", nil)
+			return
+		}
+	}
+	cfg.metrics.handler.ServeHTTP(w, r)
 }
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"internal/database"
+	"internal/moderation"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// moderationReloadInterval is how often each server reloads the word list
+// regardless of announcements, in case it missed one while its listener was
+// reconnecting.
+const moderationReloadInterval = 5 * time.Minute
+
+type ModerationWord struct {
+	Word      string    `json:"word"`
+	Action    string    `json:"action"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type FlaggedChirp struct {
+	Chirp     Chirp     `json:"chirp"`
+	Words     []string  `json:"words"`
+	FlaggedAt time.Time `json:"flagged_at"`
+}
+
+type FlaggedChirpPage struct {
+	Chirps     []FlaggedChirp `json:"chirps"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+// reloadModerationWords rebuilds the word filter from the database and swaps
+// it in. Chirps being checked meanwhile finish against the old filter.
+func (cfg *apiConfig) reloadModerationWords(ctx context.Context) error {
+	dbWords, err := cfg.db.GetModerationWords(ctx)
+	if err != nil {
+		return err
+	}
+	rules := make([]moderation.Rule, 0, len(dbWords))
+	for _, dbWord := range dbWords {
+		action, err := moderation.ParseAction(dbWord.Action)
+		if err != nil {
+			slog.WarnContext(ctx, "Skipping moderation word", "word", dbWord.Word, "err", err)
+			continue
+		}
+		rules = append(rules, moderation.Rule{Word: dbWord.Word, Action: action})
+	}
+	cfg.wordFilter.Store(moderation.New(rules))
+	return nil
+}
+
+// watchModerationWords reloads the word filter whenever an admin changes the
+// list through any server, until ctx is done.
+func (cfg *apiConfig) watchModerationWords(ctx context.Context) {
+	events := cfg.realtime.subscribe()
+	defer func() { cfg.realtime.unsubscribe(events) }()
+	ticker := time.NewTicker(moderationReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		reload := false
+		select {
+		case <-ctx.Done():
+			return
+		case ev, ok := <-events:
+			if !ok {
+				// Dropped by the hub; an announcement may have been lost.
+				events = cfg.realtime.subscribe()
+				reload = true
+			} else {
+				reload = ev.channel == moderationWordsChannel
+			}
+		case <-ticker.C:
+			reload = true
+		}
+		if reload {
+			if err := cfg.reloadModerationWords(ctx); err != nil {
+				slog.ErrorContext(ctx, "Could not reload moderation words", "err", err)
+			}
+		}
+	}
+}
+
+// moderationWordsChanged applies a change to the word list on this server
+// and announces it to the others.
+func (cfg *apiConfig) moderationWordsChanged(ctx context.Context, adminID uuid.UUID) {
+	if err := cfg.reloadModerationWords(ctx); err != nil {
+		slog.ErrorContext(ctx, "Could not reload moderation words", "err", err)
+	}
+	if err := cfg.db.NotifyModerationWordsChanged(ctx, adminID.String()); err != nil {
+		slog.ErrorContext(ctx, "Could not announce moderation word change", "err", err)
+	}
+}
+
+func (cfg *apiConfig) handlerGetModerationWords(w http.ResponseWriter, r *http.Request) {
+	_, err := cfg.authenticatedStaff(r, roleAdmin)
+	if errors.Is(err, errNotStaff) {
+		respondWithError(w, http.StatusForbidden, "Admins only", nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized access", nil)
+		return
+	}
+
+	dbWords, err := cfg.db.GetModerationWords(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not retrieve moderation words from DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve words", nil)
+		return
+	}
+	resp := []ModerationWord{}
+	for _, dbWord := range dbWords {
+		resp = append(resp, ModerationWord{
+			Word:      dbWord.Word,
+			Action:    dbWord.Action,
+			UpdatedAt: dbWord.UpdatedAt,
+		})
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+// handlerPutModerationWord adds a word to the list or changes its action.
+func (cfg *apiConfig) handlerPutModerationWord(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Word   string `json:"word"`
+		Action string `json:"action"`
+	}
+	adminID, err := cfg.authenticatedStaff(r, roleAdmin)
+	if errors.Is(err, errNotStaff) {
+		respondWithError(w, http.StatusForbidden, "Admins only", nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized access", nil)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", nil)
+		return
+	}
+	// Stored normalized, so spellings that match the same text share an
+	// entry.
+	word := moderation.Normalize(params.Word)
+	if word == "" {
+		respondWithError(w, http.StatusBadRequest, "Word is required", nil)
+		return
+	}
+	action, err := moderation.ParseAction(params.Action)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Action must be mask, flag or reject", nil)
+		return
+	}
+
+	dbWord, err := cfg.db.UpsertModerationWord(r.Context(), database.UpsertModerationWordParams{
+		Word:   word,
+		Action: action.String(),
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not save moderation word in DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't save word", nil)
+		return
+	}
+	cfg.moderationWordsChanged(r.Context(), adminID)
+	respondWithJSON(w, http.StatusOK, ModerationWord{
+		Word:      dbWord.Word,
+		Action:    dbWord.Action,
+		UpdatedAt: dbWord.UpdatedAt,
+	})
+}
+
+func (cfg *apiConfig) handlerDeleteModerationWord(w http.ResponseWriter, r *http.Request) {
+	adminID, err := cfg.authenticatedStaff(r, roleAdmin)
+	if errors.Is(err, errNotStaff) {
+		respondWithError(w, http.StatusForbidden, "Admins only", nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized access", nil)
+		return
+	}
+
+	deleted, err := cfg.db.DeleteModerationWord(r.Context(), moderation.Normalize(r.PathValue("word")))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not delete moderation word from DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't delete word", nil)
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "Couldn't find word", nil)
+		return
+	}
+	cfg.moderationWordsChanged(r.Context(), adminID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerGetFlaggedChirps lists chirps that were let through with a word
+// marked for review, newest first.
+func (cfg *apiConfig) handlerGetFlaggedChirps(w http.ResponseWriter, r *http.Request) {
+	moderatorID, err := cfg.authenticatedStaff(r, roleModerator)
+	if errors.Is(err, errNotStaff) {
+		respondWithError(w, http.StatusForbidden, "Moderators only", nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized access", nil)
+		return
+	}
+	p, err := parsePage(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	rows, err := cfg.db.GetChirpFlags(r.Context(), database.GetChirpFlagsParams{
+		CursorCreatedAt: p.cursorCreatedAt,
+		CursorID:        p.cursorID,
+		PageSize:        p.size,
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not retrieve flagged chirps from DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve flagged chirps", nil)
+		return
+	}
+	dbChirps := make([]database.Chirp, 0, len(rows))
+	for _, row := range rows {
+		dbChirps = append(dbChirps, row.Chirp)
+	}
+	chirps, err := cfg.loadChirps(r.Context(), moderatorID, dbChirps)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not load chirps from DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve flagged chirps", nil)
+		return
+	}
+
+	resp := FlaggedChirpPage{Chirps: []FlaggedChirp{}}
+	for i, row := range rows {
+		resp.Chirps = append(resp.Chirps, FlaggedChirp{
+			Chirp:     chirps[i],
+			Words:     row.Words,
+			FlaggedAt: row.FlaggedAt,
+		})
+	}
+	if len(rows) > 0 {
+		last := rows[len(rows)-1]
+		resp.NextCursor = p.nextCursor(len(rows), last.FlaggedAt, last.Chirp.ID)
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"github.com/google/uuid"
+	"internal/database"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+const (
+	notificationMention = "mention"
+	notificationReply   = "reply"
+	notificationLike    = "like"
+	notificationFollow  = "follow"
+	notificationRechirp = "rechirp"
+)
+
+type Notification struct {
+	ID          uuid.UUID  `json:"id"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	Kind        string     `json:"kind"`
+	ActorID     uuid.UUID  `json:"actor_id"`
+	OthersCount int32      `json:"others_count"`
+	ChirpID     *uuid.UUID `json:"chirp_id,omitempty"`
+	Read        bool       `json:"read"`
+}
+
+type NotificationPage struct {
+	Notifications []Notification `json:"notifications"`
+	NextCursor    string         `json:"next_cursor,omitempty"`
+}
+
+// notify records a notification for userID about something actorID did.
+// Likes, rechirps and follows are grouped: while unread, further events of
+// the same kind on the same chirp bump the existing notification instead of
+// adding a new one, once for each distinct actor. Nothing is recorded for
+// actors who are blocked, muted or shadow banned. Failures are logged
+// rather than failing the request that triggered the notification.
+func (cfg *apiConfig) notify(ctx context.Context, userID uuid.UUID, kind string, actorID uuid.UUID, chirpID uuid.NullUUID) {
+	groupKey := sql.NullString{}
+	switch kind {
+	case notificationLike, notificationRechirp:
+		groupKey = sql.NullString{String: kind + ":" + chirpID.UUID.String(), Valid: true}
+	case notificationFollow:
+		groupKey = sql.NullString{String: kind, Valid: true}
+	}
+
+	err := cfg.db.CreateNotification(ctx, database.CreateNotificationParams{
+		UserID:   userID,
+		Kind:     kind,
+		ActorID:  actorID,
+		ChirpID:  chirpID,
+		GroupKey: groupKey,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Could not create notification", "kind", kind, "err", err)
+	}
+}
+
+// notifyMentions notifies the users a new chirp mentions. The mentions are
+// recorded when the chirp is created; users with a block in either direction
+// were silently dropped then.
+func (cfg *apiConfig) notifyMentions(ctx context.Context, chirp database.Chirp) {
+	if err := cfg.db.NotifyMentions(ctx, chirp.ID); err != nil {
+		slog.ErrorContext(ctx, "Could not create mention notifications", "err", err)
+	}
+}
+
+func (cfg *apiConfig) handlerGetNotifications(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized access", nil)
+		return
+	}
+	p, err := parsePage(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	rows, err := cfg.db.GetNotifications(r.Context(), database.GetNotificationsParams{
+		UserID:          userID,
+		CursorUpdatedAt: p.cursorCreatedAt,
+		CursorID:        p.cursorID,
+		PageSize:        p.size,
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not retrieve notifications from DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve notifications", nil)
+		return
+	}
+
+	resp := NotificationPage{Notifications: []Notification{}}
+	for _, row := range rows {
+		notification := Notification{
+			ID:          row.ID,
+			CreatedAt:   row.CreatedAt,
+			UpdatedAt:   row.UpdatedAt,
+			Kind:        row.Kind,
+			ActorID:     row.ActorID,
+			OthersCount: row.ActorCount - 1,
+			Read:        row.ReadAt.Valid,
+		}
+		if row.ChirpID.Valid {
+			notification.ChirpID = &row.ChirpID.UUID
+		}
+		resp.Notifications = append(resp.Notifications, notification)
+	}
+	if len(rows) > 0 {
+		last := rows[len(rows)-1]
+		resp.NextCursor = p.nextCursor(len(rows), last.UpdatedAt, last.ID)
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+func (cfg *apiConfig) handlerUnreadNotificationCount(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Unread int64 `json:"unread"`
+	}
+	userID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized access", nil)
+		return
+	}
+	count, err := cfg.db.CountUnreadNotifications(r.Context(), userID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not count notifications in DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't count notifications", nil)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, response{Unread: count})
+}
+
+func (cfg *apiConfig) handlerMarkNotificationRead(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized access", nil)
+		return
+	}
+	notificationID, err := uuid.Parse(r.PathValue("notificationID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid notification ID", nil)
+		return
+	}
+	marked, err := cfg.db.MarkNotificationRead(r.Context(), database.MarkNotificationReadParams{
+		ID:     notificationID,
+		UserID: userID,
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not mark notification read in DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't mark notification read", nil)
+		return
+	}
+	if marked == 0 {
+		respondWithError(w, http.StatusNotFound, "Couldn't find notification", nil)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerMarkAllNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized access", nil)
+		return
+	}
+	err = cfg.db.MarkAllNotificationsRead(r.Context(), userID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not mark notifications read in DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't mark notifications read", nil)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
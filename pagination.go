@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"github.com/google/uuid"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// page is a keyset position in a listing ordered by (created_at, id).
+type page struct {
+	cursorCreatedAt time.Time
+	cursorID        uuid.UUID
+	size            int32
+}
+
+// parsePage reads the page for a newest-first listing.
+func parsePage(r *http.Request) (page, error) {
+	return parsePageFrom(r, page{
+		cursorCreatedAt: time.Date(9999, time.December, 31, 0, 0, 0, 0, time.UTC),
+		cursorID:        uuid.Max,
+		size:            defaultPageSize,
+	})
+}
+
+// parseAscendingPage reads the page for an oldest-first listing.
+func parseAscendingPage(r *http.Request) (page, error) {
+	return parsePageFrom(r, page{
+		cursorCreatedAt: time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC),
+		cursorID:        uuid.Nil,
+		size:            defaultPageSize,
+	})
+}
+
+func parsePageFrom(r *http.Request, p page) (page, error) {
+	if limit := r.URL.Query().Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 {
+			return page{}, errors.New("invalid limit")
+		}
+		p.size = int32(min(n, maxPageSize))
+	}
+
+	if cursor := r.URL.Query().Get("cursor"); cursor != "" {
+		createdAt, id, err := decodeCursor(cursor)
+		if err != nil {
+			return page{}, err
+		}
+		p.cursorCreatedAt = createdAt
+		p.cursorID = id
+	}
+	return p, nil
+}
+
+// nextCursor returns the cursor for the page after one ending at the given row,
+// or "" when the page came back short and there is nothing more to fetch.
+func (p page) nextCursor(count int, createdAt time.Time, id uuid.UUID) string {
+	if count < int(p.size) {
+		return ""
+	}
+	return encodeCursor(createdAt, id)
+}
+
+func encodeCursor(createdAt time.Time, id uuid.UUID) string {
+	raw := createdAt.UTC().Format(time.RFC3339Nano) + "|" + id.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(cursor string) (time.Time, uuid.UUID, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, uuid.Nil, errors.New("invalid cursor")
+	}
+	createdAtString, idString, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return time.Time{}, uuid.Nil, errors.New("invalid cursor")
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, createdAtString)
+	if err != nil {
+		return time.Time{}, uuid.Nil, errors.New("invalid cursor")
+	}
+	id, err := uuid.Parse(idString)
+	if err != nil {
+		return time.Time{}, uuid.Nil, errors.New("invalid cursor")
+	}
+	return createdAt, id, nil
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/google/uuid"
+	"internal/content"
+	"internal/database"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+)
+
+const (
+	minPollOptions         = 2
+	maxPollOptions         = 4
+	maxPollOptionLength    = 25
+	defaultPollDuration    = 24 * 60
+	minPollDurationMinutes = 5
+	maxPollDurationMinutes = 7 * 24 * 60
+)
+
+// pollParams is a poll as submitted with a chirp or draft. It runs for
+// DurationMinutes from when the chirp is published.
+type pollParams struct {
+	Options         []string `json:"options"`
+	DurationMinutes int32    `json:"duration_minutes"`
+}
+
+// Poll is embedded in a chirp. Tallies are left out until the viewer has
+// voted or the poll has closed, so early results can't sway the vote.
+type Poll struct {
+	Options    []PollOption `json:"options"`
+	ClosesAt   time.Time    `json:"closes_at"`
+	Closed     bool         `json:"closed"`
+	TotalVotes *int64       `json:"total_votes,omitempty"`
+	MyVote     *int16       `json:"my_vote,omitempty"`
+}
+
+type PollOption struct {
+	Label string `json:"label"`
+	Votes *int64 `json:"votes,omitempty"`
+}
+
+// validatePoll checks a submitted poll and fills in its default duration.
+func validatePoll(poll *pollParams) error {
+	if len(poll.Options) < minPollOptions || len(poll.Options) > maxPollOptions {
+		return &chirpError{http.StatusBadRequest, "A poll needs 2 to 4 options", nil}
+	}
+	for i, option := range poll.Options {
+		option, err := content.Check(option, maxPollOptionLength)
+		if err != nil || option == "" {
+			return &chirpError{http.StatusBadRequest, "Poll options must be 1 to 25 characters", nil}
+		}
+		if slices.Contains(poll.Options[:i], option) {
+			return &chirpError{http.StatusBadRequest, "Poll options must be different", nil}
+		}
+		poll.Options[i] = option
+	}
+	if poll.DurationMinutes == 0 {
+		poll.DurationMinutes = defaultPollDuration
+	}
+	if poll.DurationMinutes < minPollDurationMinutes || poll.DurationMinutes > maxPollDurationMinutes {
+		return &chirpError{http.StatusBadRequest, "A poll must run for 5 minutes to 7 days", nil}
+	}
+	return nil
+}
+
+// loadPolls fetches the polls on chirps with ids, with tallies as viewerID
+// may see them.
+func (cfg *apiConfig) loadPolls(ctx context.Context, viewerID uuid.UUID, ids []uuid.UUID) (map[uuid.UUID]*Poll, error) {
+	dbPolls, err := cfg.db.GetPolls(ctx, database.GetPollsParams{
+		ViewerID: viewerID,
+		ChirpIds: ids,
+	})
+	if err != nil {
+		return nil, err
+	}
+	polls := make(map[uuid.UUID]*Poll, len(dbPolls))
+	if len(dbPolls) == 0 {
+		return polls, nil
+	}
+	options, err := cfg.db.GetPollOptions(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	visible := map[uuid.UUID]bool{}
+	for _, dbPoll := range dbPolls {
+		poll := &Poll{
+			Options:  []PollOption{},
+			ClosesAt: dbPoll.ClosesAt,
+			Closed:   !time.Now().Before(dbPoll.ClosesAt),
+		}
+		if dbPoll.ViewerVote.Valid {
+			poll.MyVote = &dbPoll.ViewerVote.Int16
+		}
+		visible[dbPoll.ChirpID] = poll.Closed || poll.MyVote != nil
+		if visible[dbPoll.ChirpID] {
+			poll.TotalVotes = new(int64)
+		}
+		polls[dbPoll.ChirpID] = poll
+	}
+	for _, option := range options {
+		poll, ok := polls[option.ChirpID]
+		if !ok {
+			continue
+		}
+		pollOption := PollOption{Label: option.Label}
+		if visible[option.ChirpID] {
+			pollOption.Votes = &option.Votes
+			*poll.TotalVotes += option.Votes
+		}
+		poll.Options = append(poll.Options, pollOption)
+	}
+	return polls, nil
+}
+
+func (cfg *apiConfig) handlerVotePoll(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Option int16 `json:"option"`
+	}
+	userID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized access", nil)
+		return
+	}
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", nil)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", nil)
+		return
+	}
+
+	_, err = cfg.db.GetChirpByID(r.Context(), database.GetChirpByIDParams{
+		ID:       chirpID,
+		ViewerID: userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Couldn't retrieve chirp", nil)
+		return
+	}
+	polls, err := cfg.loadPolls(r.Context(), userID, []uuid.UUID{chirpID})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not retrieve poll from DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't vote", nil)
+		return
+	}
+	poll, ok := polls[chirpID]
+	if !ok {
+		respondWithError(w, http.StatusNotFound, "Chirp has no poll", nil)
+		return
+	}
+	if poll.Closed {
+		respondWithError(w, http.StatusConflict, "Poll is closed", nil)
+		return
+	}
+	if params.Option < 0 || int(params.Option) >= len(poll.Options) {
+		respondWithError(w, http.StatusBadRequest, "Invalid poll option", nil)
+		return
+	}
+
+	voted, err := cfg.db.CreatePollVote(r.Context(), database.CreatePollVoteParams{
+		ChirpID:  chirpID,
+		UserID:   userID,
+		Position: params.Option,
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not create poll vote in DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't vote", nil)
+		return
+	}
+	if voted == 0 {
+		respondWithError(w, http.StatusConflict, "You've already voted", nil)
+		return
+	}
+
+	polls, err = cfg.loadPolls(r.Context(), userID, []uuid.UUID{chirpID})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not retrieve poll from DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve poll", nil)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, polls[chirpID])
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"internal/auth"
+	"internal/database"
+	"internal/ratelimit"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultRateLimitRoute holds the rule for routes without one of their own.
+// They all share its buckets.
+const defaultRateLimitRoute = "*"
+
+// defaultRateLimits applies unless RATE_LIMITS is set. Routes are the
+// patterns they're registered with in main.
+const defaultRateLimits = "POST /api/login=5/m:ip;" +
+	"POST /api/users=10/h:ip;" +
+	"GET /api/users/challenge=30/h:ip;" +
+	"POST /api/refresh=30/m:ip;" +
+	"POST /api/chirps=30/m:user;" +
+	"POST /api/media=20/h:user;" +
+	"POST /api/chirps/{chirpID}/report=20/h:user;" +
+	"POST /api/users/{userID}/report=20/h:user;" +
+	"POST /api/conversations/{conversationID}/messages=60/m:user;" +
+	"*=600/m:user"
+
+// pgPruneInterval is how often each instance deletes refilled buckets.
+const pgPruneInterval = time.Minute
+
+// newRateLimiter picks the bucket store from RATE_LIMIT_STORE. It returns
+// nil when rate limiting is off.
+func newRateLimiter(cfg *apiConfig) (ratelimit.Store, error) {
+	switch os.Getenv("RATE_LIMIT_STORE") {
+	case "", "memory":
+		return ratelimit.NewMemory(), nil
+	case "postgres":
+		return &pgRateLimiter{cfg: cfg}, nil
+	case "off":
+		return nil, nil
+	default:
+		return nil, errors.New("RATE_LIMIT_STORE must be memory, postgres or off")
+	}
+}
+
+// rateLimitRules reads the per-route rules from RATE_LIMITS, falling back to
+// defaultRateLimits.
+func rateLimitRules() (map[string]ratelimit.Rule, error) {
+	rules := os.Getenv("RATE_LIMITS")
+	if rules == "" {
+		rules = defaultRateLimits
+	}
+	return ratelimit.ParseRules(rules)
+}
+
+// pgRateLimiter keeps buckets in Postgres so every instance shares them.
+// Buckets are timed by the database clock, read once the bucket is locked,
+// so skew between instances can't corrupt them.
+type pgRateLimiter struct {
+	cfg       *apiConfig
+	mu        sync.Mutex
+	nextPrune time.Time
+}
+
+func (l *pgRateLimiter) Take(ctx context.Context, key string, p ratelimit.Policy, now time.Time) (ratelimit.Result, error) {
+	l.prune(ctx, now)
+
+	var res ratelimit.Result
+	err := l.cfg.withTx(ctx, func(q *database.Queries) error {
+		// Inserts a full bucket if there's none, and either way holds the
+		// row until commit so concurrent requests take turns.
+		row, err := q.LockRateLimitBucket(ctx, database.LockRateLimitBucketParams{
+			Key:    key,
+			Tokens: float64(p.Limit),
+		})
+		if err != nil {
+			return err
+		}
+		// The clock can still step back, but a bucket's time never does.
+		now := row.Now
+		if now.Before(row.UpdatedAt) {
+			now = row.UpdatedAt
+		}
+		var bucket ratelimit.Bucket
+		bucket, res = p.Take(ratelimit.Bucket{Tokens: row.Tokens, UpdatedAt: row.UpdatedAt}, now)
+		return q.UpdateRateLimitBucket(ctx, database.UpdateRateLimitBucketParams{
+			Key:       key,
+			Tokens:    bucket.Tokens,
+			UpdatedAt: bucket.UpdatedAt,
+			FullAt:    p.FullAt(bucket),
+		})
+	})
+	return res, err
+}
+
+func (l *pgRateLimiter) prune(ctx context.Context, now time.Time) {
+	l.mu.Lock()
+	if now.Before(l.nextPrune) {
+		l.mu.Unlock()
+		return
+	}
+	l.nextPrune = now.Add(pgPruneInterval)
+	l.mu.Unlock()
+
+	if err := l.cfg.db.DeleteFullRateLimitBuckets(ctx); err != nil {
+		slog.ErrorContext(ctx, "Could not prune rate limit buckets", "err", err)
+	}
+}
+
+// clientIP is the address the request came from. Behind a proxy that sets
+// X-Forwarded-For, RATE_LIMIT_TRUST_PROXY uses the address it saw instead.
+func (cfg *apiConfig) clientIP(r *http.Request) string {
+	if cfg.trustProxy {
+		forwarded := r.Header.Values("X-Forwarded-For")
+		if len(forwarded) > 0 {
+			hops := strings.Split(forwarded[len(forwarded)-1], ",")
+			if ip := strings.TrimSpace(hops[len(hops)-1]); ip != "" {
+				return ip
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// rateLimitKey is who a request is counted against under scope. Requests
+// without a valid token or an issued, unrevoked API key are counted by
+// address, so made-up keys can't each get a bucket of their own.
+func (cfg *apiConfig) rateLimitKey(r *http.Request, scope ratelimit.Scope) string {
+	switch scope {
+	case ratelimit.ByUser:
+		if userID, err := cfg.authenticatedUserID(r); err == nil {
+			return "user:" + userID.String()
+		}
+	case ratelimit.ByAPIKey:
+		if key, err := auth.GetAPIKey(r.Header); err == nil {
+			keyID, err := cfg.db.GetAPIKeyIDByHash(r.Context(), auth.HashAPIKey(key))
+			if err == nil {
+				return "api_key:" + keyID.String()
+			}
+			if !errors.Is(err, sql.ErrNoRows) {
+				slog.ErrorContext(r.Context(), "Could not look up API key in DB", "err", err)
+			}
+		}
+	}
+	return "ip:" + cfg.clientIP(r)
+}
+
+// middlewareRateLimit throttles requests by the rule for the route mux will
+// send them to, and reports the caller's quota in RateLimit-* headers. If
+// the store fails, requests are let through.
+func (cfg *apiConfig) middlewareRateLimit(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, route := mux.Handler(r)
+		rule, ok := cfg.rateLimits[route]
+		if !ok {
+			route = defaultRateLimitRoute
+			rule, ok = cfg.rateLimits[route]
+		}
+		if !ok {
+			mux.ServeHTTP(w, r)
+			return
+		}
+
+		key := route + "|" + cfg.rateLimitKey(r, rule.Scope)
+		res, err := cfg.rateLimiter.Take(r.Context(), key, rule.Policy, time.Now())
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Could not check rate limit", "err", err)
+			mux.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("RateLimit-Policy", rule.Policy.String())
+		w.Header().Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+		if !res.Allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(res.RetryAfter)))
+			respondWithError(w, http.StatusTooManyRequests, "Too many requests, try again later", nil)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
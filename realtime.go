@@ -0,0 +1,123 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Postgres channels the realtime hub listens on. Each carries a single UUID
+// as its payload: the new chirp, the user whose notifications or sessions
+// changed, or the admin who changed the moderation word list.
+const (
+	newChirpsChannel       = "new_chirps"
+	notificationsChannel   = "notifications"
+	sessionsRevokedChannel = "sessions_revoked"
+	moderationWordsChannel = "moderation_words"
+)
+
+const realtimeSubscriberQueue = 64
+
+// How long listen waits before retrying a listener that failed, doubling
+// after each failure.
+const (
+	realtimeRetryMin = time.Second
+	realtimeRetryMax = time.Minute
+)
+
+type realtimeEvent struct {
+	channel string
+	id      uuid.UUID
+}
+
+// realtimeHub fans events announced over Postgres LISTEN/NOTIFY out to every
+// stream and socket connected to this server instance. Each instance runs its
+// own listener, so events raised through any instance reach every client.
+type realtimeHub struct {
+	mu          sync.Mutex
+	subscribers map[chan realtimeEvent]struct{}
+}
+
+func newRealtimeHub() *realtimeHub {
+	return &realtimeHub{subscribers: map[chan realtimeEvent]struct{}{}}
+}
+
+func (h *realtimeHub) subscribe() chan realtimeEvent {
+	ch := make(chan realtimeEvent, realtimeSubscriberQueue)
+	h.mu.Lock()
+	h.subscribers[ch] = struct{}{}
+	h.mu.Unlock()
+	return ch
+}
+
+func (h *realtimeHub) unsubscribe(ch chan realtimeEvent) {
+	h.mu.Lock()
+	if _, ok := h.subscribers[ch]; ok {
+		delete(h.subscribers, ch)
+		close(ch)
+	}
+	h.mu.Unlock()
+}
+
+// publish hands an event to every subscriber. A subscriber whose queue is
+// full is dropped rather than allowed to hold up everyone else; closing its
+// channel tells the connection to go away so the client can reconnect and
+// catch up.
+func (h *realtimeHub) publish(ev realtimeEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subscribers {
+		select {
+		case ch <- ev:
+		default:
+			delete(h.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// listen relays Postgres notifications into the hub. pq.Listener reconnects
+// by itself once it's listening; if it can't start, listen logs why and
+// tries again with a new one, backing off up to realtimeRetryMax.
+func (h *realtimeHub) listen(dbURL string) {
+	retry := realtimeRetryMin
+	for {
+		listener := pq.NewListener(dbURL, time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+			if err != nil {
+				slog.Warn("Realtime listener event", "event", ev, "err", err)
+			}
+		})
+		err := h.relay(listener)
+		listener.Close()
+		slog.Error("Realtime listener stopped", "retry_in", retry, "err", err)
+		time.Sleep(retry)
+		retry = min(retry*2, realtimeRetryMax)
+	}
+}
+
+// relay listens on every realtime channel and publishes what arrives until
+// the listener fails.
+func (h *realtimeHub) relay(listener *pq.Listener) error {
+	for _, channel := range []string{newChirpsChannel, notificationsChannel, sessionsRevokedChannel, moderationWordsChannel} {
+		if err := listener.Listen(channel); err != nil {
+			return fmt.Errorf("listen on %s: %w", channel, err)
+		}
+	}
+	for n := range listener.Notify {
+		// A nil notification means the connection was re-established.
+		if n == nil {
+			continue
+		}
+		id, err := uuid.Parse(n.Extra)
+		if err != nil {
+			slog.Warn("Invalid ID on channel", "channel", n.Channel, "id", n.Extra)
+			continue
+		}
+		h.publish(realtimeEvent{channel: n.Channel, id: id})
+	}
+	return errors.New("listener closed")
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/google/uuid"
+	"internal/database"
+	"internal/visibility"
+	"log/slog"
+	"net/http"
+)
+
+// ChirpRef embeds the original of a rechirp or quote. When the original has
+// been deleted only its ID is kept and Deleted is set.
+type ChirpRef struct {
+	*Chirp
+	ID      uuid.UUID `json:"id"`
+	Deleted bool      `json:"deleted,omitempty"`
+}
+
+// originalID is the chirp a rechirp of dbChirp should point at. Rechirping a
+// rechirp goes to the original rather than building a chain.
+func originalID(dbChirp database.Chirp) uuid.UUID {
+	if dbChirp.RechirpOfID.Valid {
+		return dbChirp.RechirpOfID.UUID
+	}
+	return dbChirp.ID
+}
+
+func (cfg *apiConfig) embedOriginals(ctx context.Context, viewerID uuid.UUID, chirps []Chirp, dbChirps []database.Chirp) error {
+	ids := []uuid.UUID{}
+	for _, dbChirp := range dbChirps {
+		if dbChirp.RechirpOfID.Valid {
+			ids = append(ids, dbChirp.RechirpOfID.UUID)
+		}
+		if dbChirp.QuoteOfID.Valid {
+			ids = append(ids, dbChirp.QuoteOfID.UUID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	dbOriginals, err := cfg.db.GetChirpsByIDs(ctx, database.GetChirpsByIDsParams{
+		ChirpIds: ids,
+		ViewerID: viewerID,
+	})
+	if err != nil {
+		return err
+	}
+	loaded, err := cfg.loadChirpCounts(ctx, viewerID, dbOriginals)
+	if err != nil {
+		return err
+	}
+	originals := make(map[uuid.UUID]*Chirp, len(loaded))
+	for i := range loaded {
+		originals[loaded[i].ID] = &loaded[i]
+	}
+
+	ref := func(id uuid.NullUUID) *ChirpRef {
+		if !id.Valid {
+			return nil
+		}
+		original, ok := originals[id.UUID]
+		return &ChirpRef{Chirp: original, ID: id.UUID, Deleted: !ok}
+	}
+	for i, dbChirp := range dbChirps {
+		chirps[i].RechirpOf = ref(dbChirp.RechirpOfID)
+		chirps[i].QuoteOf = ref(dbChirp.QuoteOfID)
+	}
+	return nil
+}
+
+func (cfg *apiConfig) handlerRechirp(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized access", nil)
+		return
+	}
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", nil)
+		return
+	}
+	target, err := cfg.db.GetChirpByID(r.Context(), database.GetChirpByIDParams{
+		ID:       chirpID,
+		ViewerID: userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Couldn't retrieve chirp", nil)
+		return
+	}
+	original := target
+	if target.RechirpOfID.Valid {
+		original, err = cfg.db.GetChirpByID(r.Context(), database.GetChirpByIDParams{
+			ID:       target.RechirpOfID.UUID,
+			ViewerID: userID,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusNotFound, "Couldn't retrieve chirp", nil)
+			return
+		}
+	}
+	// A rechirp would show the original to the rechirper's followers.
+	if original.Visibility != visibility.Public {
+		respondWithError(w, http.StatusForbidden, "Only public chirps can be rechirped", nil)
+		return
+	}
+	rechirpOfID := uuid.NullUUID{UUID: original.ID, Valid: true}
+
+	status := http.StatusCreated
+	rechirp, err := cfg.db.CreateRechirp(r.Context(), database.CreateRechirpParams{
+		UserID:      userID,
+		RechirpOfID: rechirpOfID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		status = http.StatusOK
+		rechirp, err = cfg.db.GetRechirp(r.Context(), database.GetRechirpParams{
+			UserID:      userID,
+			RechirpOfID: rechirpOfID,
+		})
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not create rechirp in DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't rechirp", nil)
+		return
+	}
+	if status == http.StatusCreated {
+		cfg.fanOutChirp(r.Context(), rechirp)
+		cfg.notify(r.Context(), original.UserID, notificationRechirp, userID, rechirpOfID)
+	}
+
+	resp, err := cfg.loadChirp(r.Context(), userID, rechirp)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not load rechirp from DB", "err", err)
+		resp = newChirp(rechirp)
+	}
+	respondWithJSON(w, status, resp)
+}
+
+func (cfg *apiConfig) handlerUndoRechirp(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized access", nil)
+		return
+	}
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", nil)
+		return
+	}
+
+	// Like rechirping, undoing accepts a rechirp in place of its original.
+	// The original may since have been deleted or hidden from the user, in
+	// which case the ID can only be the original's.
+	target, err := cfg.db.GetChirpByID(r.Context(), database.GetChirpByIDParams{
+		ID:       chirpID,
+		ViewerID: userID,
+	})
+	if err == nil {
+		chirpID = originalID(target)
+	}
+
+	deleted, err := cfg.db.DeleteRechirp(r.Context(), database.DeleteRechirpParams{
+		UserID:      userID,
+		RechirpOfID: uuid.NullUUID{UUID: chirpID, Valid: true},
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not delete rechirp from DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't undo rechirp", nil)
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "Couldn't find rechirp", nil)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -1,20 +1,20 @@
 package main
 
 import (
+	"context"
+	"github.com/google/uuid"
 	"internal/auth"
+	"log/slog"
 	"net/http"
-	"strings"
+	"time"
 )
 
 func (cfg *apiConfig) handlerRefresh(w http.ResponseWriter, r *http.Request) {
 	type Token struct {
 		Token string `json:"token"`
 	}
-	var refreshToken = ""
-	if val, ok := r.Header["Authorization"]; ok {
-		splits := strings.Split(val[0], " ")
-		refreshToken = splits[1]
-	} else {
+	refreshToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
 		respondWithError(w, http.StatusUnauthorized, "Unauthorized access", nil)
 		return
 	}
@@ -22,6 +22,22 @@ func (cfg *apiConfig) handlerRefresh(w http.ResponseWriter, r *http.Request) {
 	refreshTokenDetails, err := cfg.db.GetUserFromRefreshToken(r.Context(), refreshToken)
 	if err != nil {
 		respondWithError(w, http.StatusUnauthorized, "Something went wrong", nil)
+		return
+	}
+	if refreshTokenDetails.RevokedAt.Valid || time.Now().After(refreshTokenDetails.ExpiresAt) {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized access", nil)
+		return
+	}
+	// Restricted users can't mint new access tokens, even with a refresh
+	// token that outlived the ban or suspension.
+	user, err := cfg.db.GetUserByID(r.Context(), refreshTokenDetails.UserID)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized access", nil)
+		return
+	}
+	if restricted := accountRestriction(user); restricted != "" {
+		respondWithError(w, http.StatusForbidden, restricted, nil)
+		return
 	}
 
 	token, err := auth.MakeJWT(refreshTokenDetails.UserID, cfg.secretToken)
@@ -33,3 +49,32 @@ func (cfg *apiConfig) handlerRefresh(w http.ResponseWriter, r *http.Request) {
 		Token: token,
 	})
 }
+
+// handlerRevoke revokes a refresh token and tells every server instance to
+// drop the user's realtime connections.
+func (cfg *apiConfig) handlerRevoke(w http.ResponseWriter, r *http.Request) {
+	refreshToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized access", nil)
+		return
+	}
+
+	revoked, err := cfg.db.RevokeRefreshToken(r.Context(), refreshToken)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized access", nil)
+		return
+	}
+	cfg.revokeSessions(r.Context(), revoked.UserID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// revokeSessions records that the user's access tokens issued until now are
+// no longer good for realtime connections, and drops the ones already open.
+func (cfg *apiConfig) revokeSessions(ctx context.Context, userID uuid.UUID) {
+	if err := cfg.db.RevokeSessions(ctx, userID); err != nil {
+		slog.ErrorContext(ctx, "Could not revoke sessions", "err", err)
+	}
+	if err := cfg.db.NotifySessionRevoked(ctx, userID.String()); err != nil {
+		slog.ErrorContext(ctx, "Could not announce session revocation", "err", err)
+	}
+}
@@ -0,0 +1,574 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"internal/database"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// Report statuses. Open and claimed reports make up the moderation queue.
+const (
+	reportOpen      = "open"
+	reportClaimed   = "claimed"
+	reportResolved  = "resolved"
+	reportDismissed = "dismissed"
+)
+
+// Actions recorded in the moderation log. A report is resolved with
+// hide_chirp, suspend or ban.
+const (
+	logClaim         = "claim"
+	logDismiss       = "dismiss"
+	logHideChirp     = "hide_chirp"
+	logSuspend       = "suspend"
+	logBan           = "ban"
+	logShadowBan     = "shadow_ban"
+	logLiftShadowBan = "lift_shadow_ban"
+	logReleaseChirp  = "release_chirp"
+	logRejectChirp   = "reject_chirp"
+)
+
+// maxSuspension caps how long a single decision can suspend someone for;
+// anything longer should be a ban.
+const maxSuspension = 365 * 24 * time.Hour
+
+const reasonSpam = "spam"
+
+var reportReasons = []string{reasonSpam, "harassment", "hate", "violence", "sexual", "self_harm", "impersonation", "other"}
+
+const maxReportDetailsLength = 1000
+
+type Report struct {
+	ID         uuid.UUID  `json:"id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	ReporterID uuid.UUID  `json:"reporter_id"`
+	UserID     uuid.UUID  `json:"user_id"`
+	ChirpID    *uuid.UUID `json:"chirp_id,omitempty"`
+	Reason     string     `json:"reason"`
+	Details    string     `json:"details"`
+	Status     string     `json:"status"`
+	ClaimedBy  *uuid.UUID `json:"claimed_by,omitempty"`
+	ClaimedAt  *time.Time `json:"claimed_at,omitempty"`
+	ClosedAt   *time.Time `json:"closed_at,omitempty"`
+	Chirp      *Chirp     `json:"chirp,omitempty"`
+}
+
+type ReportPage struct {
+	Reports    []Report `json:"reports"`
+	NextCursor string   `json:"next_cursor,omitempty"`
+}
+
+type ModerationLogEntry struct {
+	ID             uuid.UUID  `json:"id"`
+	CreatedAt      time.Time  `json:"created_at"`
+	ModeratorID    uuid.UUID  `json:"moderator_id"`
+	Action         string     `json:"action"`
+	ReportID       *uuid.UUID `json:"report_id,omitempty"`
+	UserID         *uuid.UUID `json:"user_id,omitempty"`
+	ChirpID        *uuid.UUID `json:"chirp_id,omitempty"`
+	SuspendedUntil *time.Time `json:"suspended_until,omitempty"`
+	Note           string     `json:"note"`
+}
+
+type ModerationLogPage struct {
+	Entries    []ModerationLogEntry `json:"entries"`
+	NextCursor string               `json:"next_cursor,omitempty"`
+}
+
+func newReport(dbReport database.Report) Report {
+	report := Report{
+		ID:         dbReport.ID,
+		CreatedAt:  dbReport.CreatedAt,
+		UpdatedAt:  dbReport.UpdatedAt,
+		ReporterID: dbReport.ReporterID,
+		UserID:     dbReport.UserID,
+		Reason:     dbReport.Reason,
+		Details:    dbReport.Details,
+		Status:     dbReport.Status,
+	}
+	if dbReport.ChirpID.Valid {
+		report.ChirpID = &dbReport.ChirpID.UUID
+	}
+	if dbReport.ClaimedBy.Valid {
+		report.ClaimedBy = &dbReport.ClaimedBy.UUID
+	}
+	if dbReport.ClaimedAt.Valid {
+		report.ClaimedAt = &dbReport.ClaimedAt.Time
+	}
+	if dbReport.ClosedAt.Valid {
+		report.ClosedAt = &dbReport.ClosedAt.Time
+	}
+	return report
+}
+
+func newModerationLogEntry(dbEntry database.ModerationLog) ModerationLogEntry {
+	entry := ModerationLogEntry{
+		ID:          dbEntry.ID,
+		CreatedAt:   dbEntry.CreatedAt,
+		ModeratorID: dbEntry.ModeratorID,
+		Action:      dbEntry.Action,
+		Note:        dbEntry.Note,
+	}
+	if dbEntry.ReportID.Valid {
+		entry.ReportID = &dbEntry.ReportID.UUID
+	}
+	if dbEntry.UserID.Valid {
+		entry.UserID = &dbEntry.UserID.UUID
+	}
+	if dbEntry.ChirpID.Valid {
+		entry.ChirpID = &dbEntry.ChirpID.UUID
+	}
+	if dbEntry.SuspendedUntil.Valid {
+		entry.SuspendedUntil = &dbEntry.SuspendedUntil.Time
+	}
+	return entry
+}
+
+// accountRestriction explains why user can't log in or chirp right now, or
+// returns "" if they can.
+func accountRestriction(user database.User) string {
+	if user.BannedAt.Valid {
+		return "Your account has been banned"
+	}
+	if user.SuspendedUntil.Valid && time.Now().Before(user.SuspendedUntil.Time) {
+		return "Your account is suspended until " + user.SuspendedUntil.Time.UTC().Format(time.RFC3339)
+	}
+	return ""
+}
+
+type reportParams struct {
+	Reason  string `json:"reason"`
+	Details string `json:"details"`
+}
+
+func decodeReport(r *http.Request) (reportParams, string) {
+	decoder := json.NewDecoder(r.Body)
+	params := reportParams{}
+	if err := decoder.Decode(&params); err != nil {
+		return params, "Couldn't decode parameters"
+	}
+	if !slices.Contains(reportReasons, params.Reason) {
+		return params, "Reason must be one of spam, harassment, hate, violence, sexual, self_harm, impersonation or other"
+	}
+	if len(params.Details) > maxReportDetailsLength {
+		return params, "Details are too long"
+	}
+	return params, ""
+}
+
+// fileReport stores a report and responds with it. Reporting the same thing
+// again while the first report is pending is a conflict.
+func (cfg *apiConfig) fileReport(w http.ResponseWriter, r *http.Request, arg database.CreateReportParams) {
+	dbReport, err := cfg.db.CreateReport(r.Context(), arg)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusConflict, "You've already reported this", nil)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not create report in DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create report", nil)
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, newReport(dbReport))
+}
+
+func (cfg *apiConfig) handlerReportChirp(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized access", nil)
+		return
+	}
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", nil)
+		return
+	}
+	params, invalid := decodeReport(r)
+	if invalid != "" {
+		respondWithError(w, http.StatusBadRequest, invalid, nil)
+		return
+	}
+
+	chirp, err := cfg.db.GetChirpByID(r.Context(), database.GetChirpByIDParams{
+		ID:       chirpID,
+		ViewerID: userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Couldn't retrieve chirp", nil)
+		return
+	}
+	if chirp.UserID == userID {
+		respondWithError(w, http.StatusBadRequest, "You can't report your own chirp", nil)
+		return
+	}
+
+	cfg.fileReport(w, r, database.CreateReportParams{
+		ReporterID: userID,
+		UserID:     chirp.UserID,
+		ChirpID:    uuid.NullUUID{UUID: chirp.ID, Valid: true},
+		Reason:     params.Reason,
+		Details:    params.Details,
+	})
+}
+
+func (cfg *apiConfig) handlerReportUser(w http.ResponseWriter, r *http.Request) {
+	userID, targetID, ok := cfg.targetUser(w, r)
+	if !ok {
+		return
+	}
+	params, invalid := decodeReport(r)
+	if invalid != "" {
+		respondWithError(w, http.StatusBadRequest, invalid, nil)
+		return
+	}
+
+	cfg.fileReport(w, r, database.CreateReportParams{
+		ReporterID: userID,
+		UserID:     targetID,
+		Reason:     params.Reason,
+		Details:    params.Details,
+	})
+}
+
+// handlerGetReports lists reports oldest first, so the queue is worked in
+// the order reports came in. It shows open and claimed reports unless a
+// status is asked for.
+func (cfg *apiConfig) handlerGetReports(w http.ResponseWriter, r *http.Request) {
+	moderatorID, err := cfg.authenticatedStaff(r, roleModerator)
+	if errors.Is(err, errNotStaff) {
+		respondWithError(w, http.StatusForbidden, "Moderators only", nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized access", nil)
+		return
+	}
+	p, err := parseAscendingPage(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	statuses := []string{reportOpen, reportClaimed}
+	if status := r.URL.Query().Get("status"); status != "" {
+		if !slices.Contains([]string{reportOpen, reportClaimed, reportResolved, reportDismissed}, status) {
+			respondWithError(w, http.StatusBadRequest, "Invalid status", nil)
+			return
+		}
+		statuses = []string{status}
+	}
+
+	dbReports, err := cfg.db.GetReports(r.Context(), database.GetReportsParams{
+		Statuses:        statuses,
+		CursorCreatedAt: p.cursorCreatedAt,
+		CursorID:        p.cursorID,
+		PageSize:        p.size,
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not retrieve reports from DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve reports", nil)
+		return
+	}
+
+	resp := ReportPage{Reports: []Report{}}
+	chirpIDs := []uuid.UUID{}
+	for _, dbReport := range dbReports {
+		resp.Reports = append(resp.Reports, newReport(dbReport))
+		if dbReport.ChirpID.Valid {
+			chirpIDs = append(chirpIDs, dbReport.ChirpID.UUID)
+		}
+	}
+	// The reported chirps are shown whoever they were written for, since
+	// moderators can't judge what they can't see.
+	if len(chirpIDs) > 0 {
+		dbChirps, err := cfg.db.GetChirpsForStaff(r.Context(), chirpIDs)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Could not retrieve reported chirps from DB", "err", err)
+			respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve reports", nil)
+			return
+		}
+		chirps, err := cfg.loadChirps(r.Context(), moderatorID, dbChirps)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Could not load chirps from DB", "err", err)
+			respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve reports", nil)
+			return
+		}
+		byID := make(map[uuid.UUID]*Chirp, len(chirps))
+		for i := range chirps {
+			byID[chirps[i].ID] = &chirps[i]
+		}
+		for i := range resp.Reports {
+			if resp.Reports[i].ChirpID != nil {
+				resp.Reports[i].Chirp = byID[*resp.Reports[i].ChirpID]
+			}
+		}
+	}
+	if len(dbReports) > 0 {
+		last := dbReports[len(dbReports)-1]
+		resp.NextCursor = p.nextCursor(len(dbReports), last.CreatedAt, last.ID)
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+// moderatorReport authenticates a moderator and loads the {reportID} they
+// act on. It writes the error response itself and reports whether to
+// continue.
+func (cfg *apiConfig) moderatorReport(w http.ResponseWriter, r *http.Request) (uuid.UUID, database.Report, bool) {
+	moderatorID, err := cfg.authenticatedStaff(r, roleModerator)
+	if errors.Is(err, errNotStaff) {
+		respondWithError(w, http.StatusForbidden, "Moderators only", nil)
+		return uuid.Nil, database.Report{}, false
+	}
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized access", nil)
+		return uuid.Nil, database.Report{}, false
+	}
+	reportID, err := uuid.Parse(r.PathValue("reportID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid report ID", nil)
+		return uuid.Nil, database.Report{}, false
+	}
+	report, err := cfg.db.GetReportByID(r.Context(), reportID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Couldn't find report", nil)
+		return uuid.Nil, database.Report{}, false
+	}
+	return moderatorID, report, true
+}
+
+// handlerClaimReport takes an open report so that no other moderator works
+// on it at the same time.
+func (cfg *apiConfig) handlerClaimReport(w http.ResponseWriter, r *http.Request) {
+	moderatorID, report, ok := cfg.moderatorReport(w, r)
+	if !ok {
+		return
+	}
+
+	var claimed database.Report
+	err := cfg.withTx(r.Context(), func(q *database.Queries) error {
+		var err error
+		claimed, err = q.ClaimReport(r.Context(), database.ClaimReportParams{
+			ModeratorID: uuid.NullUUID{UUID: moderatorID, Valid: true},
+			ID:          report.ID,
+		})
+		if err != nil {
+			return err
+		}
+		_, err = q.CreateModerationLogEntry(r.Context(), database.CreateModerationLogEntryParams{
+			ModeratorID: moderatorID,
+			Action:      logClaim,
+			ReportID:    uuid.NullUUID{UUID: report.ID, Valid: true},
+			UserID:      uuid.NullUUID{UUID: report.UserID, Valid: true},
+			ChirpID:     report.ChirpID,
+		})
+		return err
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusConflict, "Report isn't open", nil)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not claim report in DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't claim report", nil)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, newReport(claimed))
+}
+
+// closeReport closes report with status, applies the decision through apply
+// and records it in the moderation log, all in one transaction.
+func (cfg *apiConfig) closeReport(ctx context.Context, moderatorID uuid.UUID, report database.Report, status string, entry database.CreateModerationLogEntryParams, apply func(q *database.Queries) error) (database.Report, error) {
+	var closed database.Report
+	err := cfg.withTx(ctx, func(q *database.Queries) error {
+		var err error
+		closed, err = q.CloseReport(ctx, database.CloseReportParams{
+			Status:      status,
+			ModeratorID: uuid.NullUUID{UUID: moderatorID, Valid: true},
+			ID:          report.ID,
+		})
+		if err != nil {
+			return err
+		}
+		if apply != nil {
+			if err := apply(q); err != nil {
+				return err
+			}
+		}
+		// Spam reports about chirps train the spam classifier: upheld ones
+		// as spam, dismissed ones as not.
+		if report.Reason == reasonSpam && report.ChirpID.Valid {
+			err = q.CreateSpamLabel(ctx, database.CreateSpamLabelParams{
+				Spam:        status == reportResolved,
+				ModeratorID: uuid.NullUUID{UUID: moderatorID, Valid: true},
+				ChirpID:     report.ChirpID.UUID,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		entry.ModeratorID = moderatorID
+		entry.ReportID = uuid.NullUUID{UUID: report.ID, Valid: true}
+		_, err = q.CreateModerationLogEntry(ctx, entry)
+		return err
+	})
+	return closed, err
+}
+
+// handlerResolveReport upholds a report by hiding the reported chirp,
+// suspending its author for a number of hours or banning them.
+func (cfg *apiConfig) handlerResolveReport(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Action         string `json:"action"`
+		SuspendedHours int    `json:"suspended_hours"`
+		Note           string `json:"note"`
+	}
+	moderatorID, report, ok := cfg.moderatorReport(w, r)
+	if !ok {
+		return
+	}
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", nil)
+		return
+	}
+
+	entry := database.CreateModerationLogEntryParams{
+		Action: params.Action,
+		UserID: uuid.NullUUID{UUID: report.UserID, Valid: true},
+		Note:   params.Note,
+	}
+	var apply func(q *database.Queries) error
+	switch params.Action {
+	case logHideChirp:
+		if !report.ChirpID.Valid {
+			respondWithError(w, http.StatusBadRequest, "Report isn't about a chirp", nil)
+			return
+		}
+		entry.ChirpID = report.ChirpID
+		apply = func(q *database.Queries) error {
+			return q.HideChirp(r.Context(), report.ChirpID.UUID)
+		}
+	case logSuspend:
+		duration := time.Duration(params.SuspendedHours) * time.Hour
+		if duration <= 0 || duration > maxSuspension {
+			respondWithError(w, http.StatusBadRequest, "Suspension must be between 1 hour and 1 year", nil)
+			return
+		}
+		entry.ChirpID = report.ChirpID
+		entry.SuspendedUntil = sql.NullTime{Time: time.Now().UTC().Add(duration), Valid: true}
+		apply = func(q *database.Queries) error {
+			err := q.SuspendUser(r.Context(), database.SuspendUserParams{
+				ID:             report.UserID,
+				SuspendedUntil: entry.SuspendedUntil,
+			})
+			if err != nil {
+				return err
+			}
+			return q.RevokeRefreshTokensByUsers(r.Context(), []uuid.UUID{report.UserID})
+		}
+	case logBan:
+		entry.ChirpID = report.ChirpID
+		apply = func(q *database.Queries) error {
+			if err := q.BanUser(r.Context(), report.UserID); err != nil {
+				return err
+			}
+			return q.RevokeRefreshTokensByUsers(r.Context(), []uuid.UUID{report.UserID})
+		}
+	default:
+		respondWithError(w, http.StatusBadRequest, "Action must be hide_chirp, suspend or ban", nil)
+		return
+	}
+
+	closed, err := cfg.closeReport(r.Context(), moderatorID, report, reportResolved, entry, apply)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusConflict, "Report is closed or claimed by someone else", nil)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not resolve report in DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't resolve report", nil)
+		return
+	}
+	// Refresh tokens were revoked with the decision; realtime connections
+	// are dropped here.
+	if params.Action == logSuspend || params.Action == logBan {
+		cfg.revokeSessions(r.Context(), report.UserID)
+	}
+	respondWithJSON(w, http.StatusOK, newReport(closed))
+}
+
+// handlerDismissReport closes a report without taking action.
+func (cfg *apiConfig) handlerDismissReport(w http.ResponseWriter, r *http.Request) {
+	moderatorID, report, ok := cfg.moderatorReport(w, r)
+	if !ok {
+		return
+	}
+	note, err := decodeModerationNote(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", nil)
+		return
+	}
+
+	closed, err := cfg.closeReport(r.Context(), moderatorID, report, reportDismissed, database.CreateModerationLogEntryParams{
+		Action:  logDismiss,
+		UserID:  uuid.NullUUID{UUID: report.UserID, Valid: true},
+		ChirpID: report.ChirpID,
+		Note:    note,
+	}, nil)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusConflict, "Report is closed or claimed by someone else", nil)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not dismiss report in DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't dismiss report", nil)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, newReport(closed))
+}
+
+// handlerGetModerationLog lists moderation decisions, newest first.
+func (cfg *apiConfig) handlerGetModerationLog(w http.ResponseWriter, r *http.Request) {
+	_, err := cfg.authenticatedStaff(r, roleModerator)
+	if errors.Is(err, errNotStaff) {
+		respondWithError(w, http.StatusForbidden, "Moderators only", nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized access", nil)
+		return
+	}
+	p, err := parsePage(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	dbEntries, err := cfg.db.GetModerationLog(r.Context(), database.GetModerationLogParams{
+		CursorCreatedAt: p.cursorCreatedAt,
+		CursorID:        p.cursorID,
+		PageSize:        p.size,
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not retrieve moderation log from DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve moderation log", nil)
+		return
+	}
+	resp := ModerationLogPage{Entries: []ModerationLogEntry{}}
+	for _, dbEntry := range dbEntries {
+		resp.Entries = append(resp.Entries, newModerationLogEntry(dbEntry))
+	}
+	if len(dbEntries) > 0 {
+		last := dbEntries[len(dbEntries)-1]
+		resp.NextCursor = p.nextCursor(len(dbEntries), last.CreatedAt, last.ID)
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
@@ -1,7 +1,7 @@
 package main
 
 import (
-	"log"
+	"log/slog"
 	"net/http"
 )
 
@@ -12,10 +12,9 @@ func (cfg *apiConfig) handlerReset(w http.ResponseWriter, r *http.Request) {
 	}
 	err := cfg.db.DeleteUsers(r.Context())
 	if err != nil {
-		log.Printf("Could not execute DB query: %v", err)
+		slog.ErrorContext(r.Context(), "Could not execute DB query", "err", err)
 		return
 	}
-	cfg.fileserverHits.Store(0)
 	w.WriteHeader(http.StatusOK)
-	w.Write([]byte("Hits reset to 0"))
+	w.Write([]byte("Database reset"))
 }
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"internal/database"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+type ShadowBan struct {
+	UserID      uuid.UUID  `json:"user_id"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ModeratorID *uuid.UUID `json:"moderator_id,omitempty"`
+}
+
+type ShadowBanPage struct {
+	ShadowBans []ShadowBan `json:"shadow_bans"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+}
+
+// decodeModerationNote reads the optional note a moderator leaves with a
+// decision. An empty body is fine.
+func decodeModerationNote(r *http.Request) (string, error) {
+	params := struct {
+		Note string `json:"note"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	return params.Note, nil
+}
+
+// handlerShadowBanUser hides the user's chirps from everyone else. The
+// filtering happens in can_view_chirp, so it covers every listing, timeline
+// and stream; the user sees their own chirps as before and isn't told.
+func (cfg *apiConfig) handlerShadowBanUser(w http.ResponseWriter, r *http.Request) {
+	moderatorID, targetID, ok := cfg.staffTarget(w, r, roleModerator)
+	if !ok {
+		return
+	}
+	note, err := decodeModerationNote(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", nil)
+		return
+	}
+
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		created, err := q.CreateShadowBan(r.Context(), database.CreateShadowBanParams{
+			UserID:      targetID,
+			ModeratorID: uuid.NullUUID{UUID: moderatorID, Valid: true},
+		})
+		if err != nil || created == 0 {
+			return err
+		}
+		_, err = q.CreateModerationLogEntry(r.Context(), database.CreateModerationLogEntryParams{
+			ModeratorID: moderatorID,
+			Action:      logShadowBan,
+			UserID:      uuid.NullUUID{UUID: targetID, Valid: true},
+			Note:        note,
+		})
+		return err
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not shadow-ban user in DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't shadow-ban user", nil)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerLiftShadowBan(w http.ResponseWriter, r *http.Request) {
+	moderatorID, targetID, ok := cfg.staffTarget(w, r, roleModerator)
+	if !ok {
+		return
+	}
+	note, err := decodeModerationNote(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", nil)
+		return
+	}
+
+	var deleted int64
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		var err error
+		deleted, err = q.DeleteShadowBan(r.Context(), targetID)
+		if err != nil || deleted == 0 {
+			return err
+		}
+		_, err = q.CreateModerationLogEntry(r.Context(), database.CreateModerationLogEntryParams{
+			ModeratorID: moderatorID,
+			Action:      logLiftShadowBan,
+			UserID:      uuid.NullUUID{UUID: targetID, Valid: true},
+			Note:        note,
+		})
+		return err
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not lift shadow ban in DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't lift shadow ban", nil)
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "User isn't shadow-banned", nil)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerGetShadowBans lists shadow-banned users, most recently banned first.
+func (cfg *apiConfig) handlerGetShadowBans(w http.ResponseWriter, r *http.Request) {
+	_, err := cfg.authenticatedStaff(r, roleModerator)
+	if errors.Is(err, errNotStaff) {
+		respondWithError(w, http.StatusForbidden, "Moderators only", nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized access", nil)
+		return
+	}
+	p, err := parsePage(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	dbBans, err := cfg.db.GetShadowBans(r.Context(), database.GetShadowBansParams{
+		CursorCreatedAt: p.cursorCreatedAt,
+		CursorID:        p.cursorID,
+		PageSize:        p.size,
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not retrieve shadow bans from DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve shadow bans", nil)
+		return
+	}
+	resp := ShadowBanPage{ShadowBans: []ShadowBan{}}
+	for _, dbBan := range dbBans {
+		ban := ShadowBan{
+			UserID:    dbBan.UserID,
+			CreatedAt: dbBan.CreatedAt,
+		}
+		if dbBan.ModeratorID.Valid {
+			ban.ModeratorID = &dbBan.ModeratorID.UUID
+		}
+		resp.ShadowBans = append(resp.ShadowBans, ban)
+	}
+	if len(dbBans) > 0 {
+		last := dbBans[len(dbBans)-1]
+		resp.NextCursor = p.nextCursor(len(dbBans), last.CreatedAt, last.UserID)
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
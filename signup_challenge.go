@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"crypto/hkdf"
+	"crypto/sha256"
+	"errors"
+	"internal/database"
+	"internal/pow"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	// signupChallengeTTL is how long a client has to solve a challenge and
+	// sign up with it.
+	signupChallengeTTL = 5 * time.Minute
+	// Difficulty rises by a bit each time signups in the last
+	// signupRateWindow double past signupRateThreshold.
+	signupRateWindow    = 10 * time.Minute
+	signupRateThreshold = 20
+
+	// signupPowLabel sets the key challenges are signed with apart from the
+	// one that signs tokens.
+	signupPowLabel = "signup-pow"
+)
+
+var errSignupProof = errors.New("invalid proof of work")
+
+type SignupChallenge struct {
+	Challenge  string    `json:"challenge"`
+	Difficulty int       `json:"difficulty"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// signupPowBits reads the base signup difficulty from SIGNUP_POW_BITS.
+// Zero, the default, turns challenges off.
+func signupPowBits() (int, error) {
+	value := os.Getenv("SIGNUP_POW_BITS")
+	if value == "" {
+		return 0, nil
+	}
+	bits, err := strconv.Atoi(value)
+	if err != nil || bits < 0 || bits > pow.MaxBits {
+		return 0, errors.New("SIGNUP_POW_BITS must be a number of bits from 0 to 32")
+	}
+	return bits, nil
+}
+
+// signupPowKey derives the key that signs signup challenges from
+// SECRET_TOKEN.
+func signupPowKey(secretToken string) ([]byte, error) {
+	return hkdf.Key(sha256.New, []byte(secretToken), nil, signupPowLabel, sha256.Size)
+}
+
+// handlerSignupChallenge issues a proof-of-work challenge to solve before
+// signing up. The client finds a solution such that the SHA-256 of
+// "<challenge>:<solution>" starts with difficulty zero bits.
+func (cfg *apiConfig) handlerSignupChallenge(w http.ResponseWriter, r *http.Request) {
+	if cfg.signupPowBits == 0 {
+		respondWithError(w, http.StatusNotFound, "Signup challenges aren't enabled", nil)
+		return
+	}
+	recent, err := cfg.db.CountRecentUsers(r.Context(), time.Now().Add(-signupRateWindow))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not count recent signups in DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't issue challenge", nil)
+		return
+	}
+	bits := pow.Difficulty(cfg.signupPowBits, int(recent), signupRateThreshold)
+	expiresAt := time.Now().Add(signupChallengeTTL)
+	challenge, err := pow.Issue(cfg.signupPowKey, bits, expiresAt)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not issue signup challenge", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't issue challenge", nil)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	respondWithJSON(w, http.StatusOK, SignupChallenge{
+		Challenge:  challenge,
+		Difficulty: bits,
+		ExpiresAt:  expiresAt.Truncate(time.Second),
+	})
+}
+
+// checkSignupProof verifies a solved challenge and spends it. It returns
+// errSignupProof if the proof doesn't hold up, and nil when challenges are
+// off.
+func (cfg *apiConfig) checkSignupProof(ctx context.Context, challenge, solution string) error {
+	if cfg.signupPowBits == 0 {
+		return nil
+	}
+	c, err := pow.Verify(cfg.signupPowKey, challenge, solution, time.Now())
+	if err != nil {
+		return errSignupProof
+	}
+	spent, err := cfg.db.SpendChallenge(ctx, database.SpendChallengeParams{
+		Now:       time.Now(),
+		Challenge: challenge,
+		ExpiresAt: c.ExpiresAt,
+	})
+	if err != nil {
+		return err
+	}
+	if spent == 0 {
+		return errSignupProof
+	}
+	return nil
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"internal/database"
+	"internal/spam"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+)
+
+const (
+	// Identical text posted by anyone within duplicateWindow counts against
+	// a new chirp.
+	duplicateWindow = time.Hour
+	// velocityWindow is how far back the author's posting rate is measured.
+	velocityWindow = 10 * time.Minute
+	// Chirps scoring in the rate-limited band are refused once the author
+	// has posted spamRateLimit chirps in the velocity window.
+	spamRateLimit = 2
+)
+
+type HeldChirp struct {
+	Chirp  Chirp     `json:"chirp"`
+	Score  float64   `json:"score"`
+	HeldAt time.Time `json:"held_at"`
+}
+
+type HeldChirpPage struct {
+	Chirps     []HeldChirp `json:"chirps"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+}
+
+// loadSpamScorer builds the spam scorer from the model trained by
+// cmd/spamtrain at SPAM_MODEL. Without a model, chirps are scored on the
+// heuristics alone.
+func loadSpamScorer() spam.Scorer {
+	path := os.Getenv("SPAM_MODEL")
+	if path == "" {
+		return spam.Classifier{}
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		slog.Warn("Could not open spam model, scoring on heuristics only", "err", err)
+		return spam.Classifier{}
+	}
+	defer f.Close()
+	model, err := spam.Load(f)
+	if err != nil {
+		slog.Warn("Could not load spam model, scoring on heuristics only", "err", err)
+		return spam.Classifier{}
+	}
+	return spam.Classifier{Model: model}
+}
+
+// scoreChirp rates body as a new chirp by userID and decides what to do
+// with it. A chirp that should be rate-limited and comes too soon after the
+// author's last ones is refused with a *chirpError.
+func (cfg *apiConfig) scoreChirp(ctx context.Context, q *database.Queries, userID uuid.UUID, body string) (float64, spam.Verdict, error) {
+	features := spam.Features{Text: body}
+	if body != "" {
+		duplicates, err := q.CountDuplicateChirps(ctx, database.CountDuplicateChirpsParams{
+			Body:  body,
+			Since: time.Now().Add(-duplicateWindow),
+		})
+		if err != nil {
+			return 0, spam.Allow, err
+		}
+		features.Duplicates = int(duplicates)
+	}
+	recent, err := q.CountRecentChirpsByUser(ctx, database.CountRecentChirpsByUserParams{
+		UserID:    userID,
+		CreatedAt: time.Now().Add(-velocityWindow),
+	})
+	if err != nil {
+		return 0, spam.Allow, err
+	}
+	features.Recent = int(recent)
+
+	score := cfg.spamScorer.Score(features)
+	verdict := cfg.spamThresholds.Verdict(score)
+	if verdict == spam.RateLimit && recent >= spamRateLimit {
+		return score, verdict, &chirpError{http.StatusTooManyRequests, "Too many chirps, try again later", nil}
+	}
+	return score, verdict, nil
+}
+
+// handlerGetHeldChirps lists chirps held as likely spam, oldest first.
+func (cfg *apiConfig) handlerGetHeldChirps(w http.ResponseWriter, r *http.Request) {
+	moderatorID, err := cfg.authenticatedStaff(r, roleModerator)
+	if errors.Is(err, errNotStaff) {
+		respondWithError(w, http.StatusForbidden, "Moderators only", nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized access", nil)
+		return
+	}
+	p, err := parseAscendingPage(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	rows, err := cfg.db.GetHeldChirps(r.Context(), database.GetHeldChirpsParams{
+		CursorCreatedAt: p.cursorCreatedAt,
+		CursorID:        p.cursorID,
+		PageSize:        p.size,
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not retrieve held chirps from DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve held chirps", nil)
+		return
+	}
+	dbChirps := make([]database.Chirp, 0, len(rows))
+	for _, row := range rows {
+		dbChirps = append(dbChirps, row.Chirp)
+	}
+	chirps, err := cfg.loadChirps(r.Context(), moderatorID, dbChirps)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not load chirps from DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve held chirps", nil)
+		return
+	}
+
+	resp := HeldChirpPage{Chirps: []HeldChirp{}}
+	for i, row := range rows {
+		resp.Chirps = append(resp.Chirps, HeldChirp{
+			Chirp:  chirps[i],
+			Score:  row.Score,
+			HeldAt: row.HeldAt,
+		})
+	}
+	if len(rows) > 0 {
+		last := rows[len(rows)-1]
+		resp.NextCursor = p.nextCursor(len(rows), last.HeldAt, last.Chirp.ID)
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+// decideHeldChirp takes a chirp off the held list, labels it for the next
+// retraining and logs the decision, all in one transaction. It writes the
+// error response itself and reports whether it succeeded.
+func (cfg *apiConfig) decideHeldChirp(w http.ResponseWriter, r *http.Request, isSpam bool) (database.Chirp, bool) {
+	moderatorID, err := cfg.authenticatedStaff(r, roleModerator)
+	if errors.Is(err, errNotStaff) {
+		respondWithError(w, http.StatusForbidden, "Moderators only", nil)
+		return database.Chirp{}, false
+	}
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized access", nil)
+		return database.Chirp{}, false
+	}
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", nil)
+		return database.Chirp{}, false
+	}
+	note, err := decodeModerationNote(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", nil)
+		return database.Chirp{}, false
+	}
+	dbChirps, err := cfg.db.GetChirpsForStaff(r.Context(), []uuid.UUID{chirpID})
+	if err != nil || len(dbChirps) == 0 {
+		respondWithError(w, http.StatusNotFound, "Couldn't find chirp", nil)
+		return database.Chirp{}, false
+	}
+	chirp := dbChirps[0]
+
+	action := logReleaseChirp
+	if isSpam {
+		action = logRejectChirp
+	}
+	var released int64
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		var err error
+		released, err = q.DeleteHeldChirp(r.Context(), chirpID)
+		if err != nil || released == 0 {
+			return err
+		}
+		// A rejected chirp stays hidden, so its author isn't told.
+		if isSpam {
+			if err := q.HideChirp(r.Context(), chirpID); err != nil {
+				return err
+			}
+		}
+		err = q.CreateSpamLabel(r.Context(), database.CreateSpamLabelParams{
+			Spam:        isSpam,
+			ModeratorID: uuid.NullUUID{UUID: moderatorID, Valid: true},
+			ChirpID:     chirpID,
+		})
+		if err != nil {
+			return err
+		}
+		_, err = q.CreateModerationLogEntry(r.Context(), database.CreateModerationLogEntryParams{
+			ModeratorID: moderatorID,
+			Action:      action,
+			UserID:      uuid.NullUUID{UUID: chirp.UserID, Valid: true},
+			ChirpID:     uuid.NullUUID{UUID: chirpID, Valid: true},
+			Note:        note,
+		})
+		return err
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not decide held chirp in DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update held chirp", nil)
+		return database.Chirp{}, false
+	}
+	if released == 0 {
+		respondWithError(w, http.StatusNotFound, "Chirp isn't held", nil)
+		return database.Chirp{}, false
+	}
+	return chirp, true
+}
+
+// handlerReleaseHeldChirp publishes a held chirp that turned out not to be
+// spam, as if it had just been posted.
+func (cfg *apiConfig) handlerReleaseHeldChirp(w http.ResponseWriter, r *http.Request) {
+	chirp, ok := cfg.decideHeldChirp(w, r, false)
+	if !ok {
+		return
+	}
+	// publishChirp only notifies mentions it's told about, and the stream
+	// skipped the chirp while it was held.
+	cfg.publishChirp(r.Context(), chirp, nil)
+	cfg.notifyMentions(r.Context(), chirp)
+	if err := cfg.db.NotifyNewChirp(r.Context(), chirp.ID.String()); err != nil {
+		slog.ErrorContext(r.Context(), "Could not announce released chirp", "err", err)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerRejectHeldChirp(w http.ResponseWriter, r *http.Request) {
+	if _, ok := cfg.decideHeldChirp(w, r, true); !ok {
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
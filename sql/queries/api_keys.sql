@@ -0,0 +1,19 @@
+-- name: CreateAPIKey :one
+INSERT INTO api_keys (id, name, key_hash, created_at, created_by)
+VALUES (
+	gen_random_uuid(),
+	$1,
+	$2,
+	NOW(),
+	$3
+)
+RETURNING *;
+
+-- name: GetAPIKeyIDByHash :one
+SELECT id FROM api_keys
+WHERE key_hash = $1 AND revoked_at IS NULL;
+
+-- name: RevokeAPIKey :execrows
+UPDATE api_keys
+SET revoked_at = NOW()
+WHERE id = $1 AND revoked_at IS NULL;
@@ -0,0 +1,24 @@
+-- name: CreateBlock :exec
+INSERT INTO blocks (blocker_id, blocked_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteBlock :exec
+DELETE FROM blocks
+WHERE blocker_id = $1 AND blocked_id = $2;
+
+-- name: CreateMute :exec
+INSERT INTO mutes (muter_id, muted_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteMute :exec
+DELETE FROM mutes
+WHERE muter_id = $1 AND muted_id = $2;
+
+-- name: IsBlockedWithAny :one
+SELECT EXISTS (
+	SELECT 1 FROM users
+	WHERE users.id = ANY(sqlc.arg(user_ids)::uuid[])
+	AND blocked_between(users.id, sqlc.arg(user_id)::uuid)
+);
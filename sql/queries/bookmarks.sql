@@ -0,0 +1,41 @@
+-- name: CreateBookmark :exec
+INSERT INTO bookmarks (user_id, chirp_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteBookmark :exec
+DELETE FROM bookmarks
+WHERE user_id = $1 AND chirp_id = $2;
+
+-- name: GetBookmarks :many
+SELECT sqlc.embed(chirps), bookmarks.created_at AS bookmarked_at
+FROM bookmarks
+JOIN chirps ON chirps.id = bookmarks.chirp_id
+WHERE bookmarks.user_id = sqlc.arg(user_id)
+AND can_view_chirp(chirps.id, chirps.user_id, chirps.visibility, sqlc.arg(user_id)::uuid)
+AND (bookmarks.created_at, bookmarks.chirp_id) < (sqlc.arg(cursor_created_at)::timestamp, sqlc.arg(cursor_id)::uuid)
+ORDER BY bookmarks.created_at DESC, bookmarks.chirp_id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: CreatePin :execrows
+INSERT INTO pinned_chirps (user_id, chirp_id, slot, created_at)
+SELECT sqlc.arg(user_id)::uuid, sqlc.arg(chirp_id)::uuid, slot, NOW()
+FROM generate_series(0, sqlc.arg(max_pins)::integer - 1) AS slot
+WHERE slot NOT IN (SELECT pinned_chirps.slot FROM pinned_chirps WHERE pinned_chirps.user_id = sqlc.arg(user_id)::uuid)
+AND NOT EXISTS (
+	SELECT 1 FROM pinned_chirps
+	WHERE pinned_chirps.user_id = sqlc.arg(user_id)::uuid AND pinned_chirps.chirp_id = sqlc.arg(chirp_id)::uuid
+)
+ORDER BY slot
+LIMIT 1;
+
+-- name: DeletePin :exec
+DELETE FROM pinned_chirps
+WHERE user_id = $1 AND chirp_id = $2;
+
+-- name: GetPinnedChirps :many
+SELECT chirps.* FROM chirps
+JOIN pinned_chirps ON pinned_chirps.chirp_id = chirps.id
+WHERE pinned_chirps.user_id = sqlc.arg(user_id)
+AND can_view_chirp(chirps.id, chirps.user_id, chirps.visibility, sqlc.arg(viewer_id)::uuid)
+ORDER BY pinned_chirps.created_at DESC;
@@ -1,18 +1,58 @@
 -- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, in_reply_to_id, quote_of_id, visibility)
 VALUES (
 	gen_random_uuid(),
 	NOW(),
 	NOW(),
 	$1,
-	$2
+	$2,
+	$3,
+	$4,
+	$5
 )
 RETURNING *;
 
 -- name: GetChirps :many
 SELECT * FROM chirps
+WHERE can_view_chirp(id, user_id, visibility, sqlc.arg(viewer_id)::uuid)
 ORDER BY created_at ASC;
 
 -- name: GetChirpByID :one
 SELECT * FROM chirps
-WHERE id = $1;
+WHERE id = sqlc.arg(id)
+AND can_view_chirp(id, user_id, visibility, sqlc.arg(viewer_id)::uuid);
+
+-- name: GetReplyCounts :many
+SELECT in_reply_to_id, COUNT(*) FROM chirps
+WHERE in_reply_to_id = ANY(sqlc.arg(chirp_ids)::uuid[])
+GROUP BY in_reply_to_id;
+
+-- name: GetChirpsByIDs :many
+SELECT * FROM chirps
+WHERE id = ANY(sqlc.arg(chirp_ids)::uuid[])
+AND can_view_chirp(id, user_id, visibility, sqlc.arg(viewer_id)::uuid);
+
+-- name: GetChirpsByAuthor :many
+SELECT * FROM chirps
+WHERE user_id = sqlc.arg(user_id)
+AND can_view_chirp(id, user_id, visibility, sqlc.arg(viewer_id)::uuid)
+AND (created_at, id) < (sqlc.arg(cursor_created_at)::timestamp, sqlc.arg(cursor_id)::uuid)
+AND NOT EXISTS (SELECT 1 FROM pinned_chirps WHERE pinned_chirps.chirp_id = chirps.id)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: DeleteChirp :execrows
+DELETE FROM chirps
+WHERE id = $1 AND user_id = $2;
+
+-- name: GetChirpsAfter :many
+SELECT * FROM chirps
+WHERE (created_at, id) > (sqlc.arg(cursor_created_at)::timestamp, sqlc.arg(cursor_id)::uuid)
+AND (sqlc.narg(author_id)::uuid IS NULL OR user_id = sqlc.narg(author_id)::uuid)
+AND (sqlc.narg(hashtag)::text IS NULL OR EXISTS (
+	SELECT 1 FROM regexp_split_to_table(body, '\s+') AS word
+	WHERE lower(rtrim(word, '.,!?;:"'')')) = lower('#' || sqlc.narg(hashtag)::text)
+))
+AND can_view_chirp(id, user_id, visibility, sqlc.arg(viewer_id)::uuid)
+ORDER BY created_at, id
+LIMIT sqlc.arg(page_size);
@@ -0,0 +1,87 @@
+-- name: CreateConversation :one
+INSERT INTO conversations (id, created_at, updated_at, direct_key)
+VALUES (gen_random_uuid(), NOW(), NOW(), sqlc.narg(direct_key))
+ON CONFLICT (direct_key) DO NOTHING
+RETURNING *;
+
+-- name: GetConversationByDirectKey :one
+SELECT * FROM conversations
+WHERE direct_key = $1;
+
+-- name: AddConversationMembers :exec
+INSERT INTO conversation_members (conversation_id, user_id, joined_at)
+SELECT sqlc.arg(conversation_id)::uuid, users.id, NOW() FROM users
+WHERE users.id = ANY(sqlc.arg(user_ids)::uuid[])
+ON CONFLICT DO NOTHING;
+
+-- name: GetConversation :one
+SELECT conversations.*, conversation_members.last_read_at,
+	(
+		SELECT COUNT(*) FROM direct_messages
+		WHERE direct_messages.conversation_id = conversations.id
+		AND direct_messages.sender_id <> sqlc.arg(user_id)::uuid
+		AND (conversation_members.last_read_at IS NULL OR direct_messages.created_at > conversation_members.last_read_at)
+		AND NOT blocked_between(direct_messages.sender_id, sqlc.arg(user_id)::uuid)
+	)::bigint AS unread_count
+FROM conversations
+JOIN conversation_members ON conversation_members.conversation_id = conversations.id
+WHERE conversations.id = sqlc.arg(id) AND conversation_members.user_id = sqlc.arg(user_id)::uuid;
+
+-- name: GetConversations :many
+SELECT conversations.*, conversation_members.last_read_at,
+	(
+		SELECT COUNT(*) FROM direct_messages
+		WHERE direct_messages.conversation_id = conversations.id
+		AND direct_messages.sender_id <> sqlc.arg(user_id)::uuid
+		AND (conversation_members.last_read_at IS NULL OR direct_messages.created_at > conversation_members.last_read_at)
+		AND NOT blocked_between(direct_messages.sender_id, sqlc.arg(user_id)::uuid)
+	)::bigint AS unread_count
+FROM conversations
+JOIN conversation_members ON conversation_members.conversation_id = conversations.id
+WHERE conversation_members.user_id = sqlc.arg(user_id)::uuid
+AND (conversations.updated_at, conversations.id) < (sqlc.arg(cursor_updated_at)::timestamp, sqlc.arg(cursor_id)::uuid)
+ORDER BY conversations.updated_at DESC, conversations.id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: GetConversationMembers :many
+SELECT * FROM conversation_members
+WHERE conversation_id = ANY(sqlc.arg(conversation_ids)::uuid[])
+ORDER BY conversation_id, joined_at, user_id;
+
+-- name: IsBlockedInConversation :one
+SELECT EXISTS (
+	SELECT 1 FROM conversation_members
+	WHERE conversation_members.conversation_id = sqlc.arg(conversation_id)
+	AND conversation_members.user_id <> sqlc.arg(user_id)::uuid
+	AND blocked_between(conversation_members.user_id, sqlc.arg(user_id)::uuid)
+);
+
+-- name: CreateDirectMessage :one
+INSERT INTO direct_messages (id, created_at, conversation_id, sender_id, body)
+VALUES (gen_random_uuid(), NOW(), $1, $2, $3)
+RETURNING *;
+
+-- name: TouchConversation :exec
+UPDATE conversations SET updated_at = $2
+WHERE id = $1 AND updated_at < $2;
+
+-- name: GetDirectMessage :one
+SELECT * FROM direct_messages
+WHERE id = $1 AND conversation_id = $2;
+
+-- name: GetDirectMessages :many
+SELECT * FROM direct_messages
+WHERE direct_messages.conversation_id = sqlc.arg(conversation_id)
+AND (direct_messages.created_at, direct_messages.id) < (sqlc.arg(cursor_created_at)::timestamp, sqlc.arg(cursor_id)::uuid)
+AND NOT blocked_between(direct_messages.sender_id, sqlc.arg(viewer_id)::uuid)
+ORDER BY direct_messages.created_at DESC, direct_messages.id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: CountRecentDirectMessages :one
+SELECT COUNT(*) FROM direct_messages
+WHERE sender_id = $1 AND created_at > $2;
+
+-- name: MarkConversationRead :exec
+UPDATE conversation_members SET last_read_at = sqlc.arg(read_at)::timestamp
+WHERE conversation_id = sqlc.arg(conversation_id) AND user_id = sqlc.arg(user_id)
+AND (last_read_at IS NULL OR last_read_at < sqlc.arg(read_at)::timestamp);
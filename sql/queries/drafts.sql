@@ -0,0 +1,42 @@
+-- name: CreateDraft :one
+INSERT INTO drafts (id, created_at, updated_at, user_id, body, in_reply_to_id, quote_of_id, mentions, media_ids, publish_at, poll_options, poll_duration_minutes, visibility)
+VALUES (gen_random_uuid(), NOW(), NOW(), $1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+RETURNING *;
+
+-- name: UpdateDraft :one
+UPDATE drafts
+SET updated_at = NOW(), body = $3, in_reply_to_id = $4, quote_of_id = $5, mentions = $6, media_ids = $7, publish_at = $8,
+	poll_options = $9, poll_duration_minutes = $10, visibility = $11, failure = NULL
+WHERE id = $1 AND user_id = $2
+RETURNING *;
+
+-- name: GetDraft :one
+SELECT * FROM drafts
+WHERE id = $1 AND user_id = $2;
+
+-- name: GetDrafts :many
+SELECT * FROM drafts
+WHERE drafts.user_id = sqlc.arg(user_id)
+AND (drafts.updated_at, drafts.id) < (sqlc.arg(cursor_updated_at)::timestamp, sqlc.arg(cursor_id)::uuid)
+ORDER BY drafts.updated_at DESC, drafts.id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: DeleteDraft :execrows
+DELETE FROM drafts
+WHERE id = $1 AND user_id = $2;
+
+-- name: ClaimDraft :one
+SELECT * FROM drafts
+WHERE id = $1 AND user_id = $2
+FOR UPDATE SKIP LOCKED;
+
+-- name: ClaimDueDraft :one
+SELECT * FROM drafts
+WHERE publish_at <= NOW() AND failure IS NULL
+ORDER BY publish_at
+LIMIT 1
+FOR UPDATE SKIP LOCKED;
+
+-- name: FailDraft :exec
+UPDATE drafts SET failure = $2, updated_at = NOW()
+WHERE id = $1;
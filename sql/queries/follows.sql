@@ -0,0 +1,41 @@
+-- name: CreateFollow :execrows
+WITH inserted AS (
+	INSERT INTO follows (follower_id, followee_id, created_at)
+	SELECT sqlc.arg(follower_id)::uuid, sqlc.arg(followee_id)::uuid, NOW()
+	WHERE NOT blocked_between(sqlc.arg(follower_id)::uuid, sqlc.arg(followee_id)::uuid)
+	ON CONFLICT DO NOTHING
+	RETURNING follower_id, followee_id
+), followee_counts AS (
+	INSERT INTO follow_counts (user_id, follower_count)
+	SELECT followee_id, 1 FROM inserted
+	ON CONFLICT (user_id) DO UPDATE SET follower_count = follow_counts.follower_count + 1
+)
+INSERT INTO follow_counts (user_id, following_count)
+SELECT follower_id, 1 FROM inserted
+ON CONFLICT (user_id) DO UPDATE SET following_count = follow_counts.following_count + 1;
+
+-- name: DeleteFollow :execrows
+WITH deleted AS (
+	DELETE FROM follows
+	WHERE follower_id = sqlc.arg(follower_id) AND followee_id = sqlc.arg(followee_id)
+	RETURNING follower_id, followee_id
+), followee_counts AS (
+	UPDATE follow_counts SET follower_count = follower_count - 1
+	WHERE user_id IN (SELECT followee_id FROM deleted)
+)
+UPDATE follow_counts SET following_count = following_count - 1
+WHERE user_id IN (SELECT follower_id FROM deleted);
+
+-- name: GetFollowers :many
+SELECT follower_id AS user_id, created_at FROM follows
+WHERE followee_id = sqlc.arg(user_id)
+AND (created_at, follower_id) < (sqlc.arg(cursor_created_at)::timestamp, sqlc.arg(cursor_id)::uuid)
+ORDER BY created_at DESC, follower_id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: GetFollowing :many
+SELECT followee_id AS user_id, created_at FROM follows
+WHERE follower_id = sqlc.arg(user_id)
+AND (created_at, followee_id) < (sqlc.arg(cursor_created_at)::timestamp, sqlc.arg(cursor_id)::uuid)
+ORDER BY created_at DESC, followee_id DESC
+LIMIT sqlc.arg(page_size);
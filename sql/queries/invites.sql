@@ -0,0 +1,80 @@
+-- name: CreateInvite :one
+INSERT INTO invites (id, code, created_at, created_by, max_uses, expires_at)
+VALUES (
+	gen_random_uuid(),
+	$1,
+	NOW(),
+	$2,
+	$3,
+	$4
+)
+RETURNING *;
+
+-- name: UseInvite :one
+UPDATE invites
+SET uses = uses + 1
+WHERE code = sqlc.arg(code)
+AND revoked_at IS NULL
+AND uses < max_uses
+AND (expires_at IS NULL OR expires_at > sqlc.arg(now)::timestamp)
+RETURNING *;
+
+-- name: RevokeInvite :execrows
+UPDATE invites
+SET revoked_at = NOW()
+WHERE code = $1 AND revoked_at IS NULL;
+
+-- name: RevokeInvitesByCreators :exec
+UPDATE invites
+SET revoked_at = NOW()
+WHERE created_by = ANY(sqlc.arg(user_ids)::uuid[]) AND revoked_at IS NULL;
+
+-- name: GetInvites :many
+SELECT * FROM invites
+WHERE (created_at, id) < (sqlc.arg(cursor_created_at)::timestamp, sqlc.arg(cursor_id)::uuid)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: GetInvitesByCreator :many
+SELECT * FROM invites
+WHERE created_by = $1
+ORDER BY created_at DESC, id DESC;
+
+-- name: CountInvitesByCreator :one
+SELECT COUNT(*) FROM invites
+WHERE created_by = $1;
+
+-- name: CountChirpsByUser :one
+SELECT COUNT(*) FROM chirps
+WHERE user_id = $1;
+
+-- name: LockUser :exec
+SELECT id FROM users
+WHERE id = $1
+FOR UPDATE;
+
+-- name: GetInviteTree :many
+WITH RECURSIVE tree AS (
+	SELECT users.id, 1 AS depth FROM users
+	WHERE users.invited_by = sqlc.arg(user_id)::uuid
+	UNION ALL
+	SELECT users.id, tree.depth + 1 FROM users
+	JOIN tree ON users.invited_by = tree.id
+)
+SELECT users.id, users.created_at, users.email, users.invited_by, users.banned_at, tree.depth::integer AS depth
+FROM tree
+JOIN users ON users.id = tree.id
+ORDER BY tree.depth, users.created_at, users.id;
+
+-- name: BanInviteTree :many
+WITH RECURSIVE tree AS (
+	SELECT users.id FROM users
+	WHERE users.invited_by = sqlc.arg(user_id)::uuid
+	UNION ALL
+	SELECT users.id FROM users
+	JOIN tree ON users.invited_by = tree.id
+)
+UPDATE users
+SET banned_at = NOW(), updated_at = NOW()
+WHERE users.id IN (SELECT tree.id FROM tree) AND users.banned_at IS NULL
+RETURNING users.id;
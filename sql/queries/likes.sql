@@ -0,0 +1,36 @@
+-- name: CreateLike :execrows
+WITH inserted AS (
+	INSERT INTO likes (chirp_id, user_id, created_at)
+	VALUES (sqlc.arg(chirp_id), sqlc.arg(user_id), NOW())
+	ON CONFLICT DO NOTHING
+	RETURNING chirp_id
+)
+INSERT INTO like_count_shards (chirp_id, shard, count)
+SELECT chirp_id, sqlc.arg(shard)::smallint, 1 FROM inserted
+ON CONFLICT (chirp_id, shard) DO UPDATE SET count = like_count_shards.count + 1;
+
+-- name: DeleteLike :execrows
+WITH deleted AS (
+	DELETE FROM likes
+	WHERE chirp_id = sqlc.arg(chirp_id) AND user_id = sqlc.arg(user_id)
+	RETURNING chirp_id
+)
+INSERT INTO like_count_shards (chirp_id, shard, count)
+SELECT chirp_id, sqlc.arg(shard)::smallint, -1 FROM deleted
+ON CONFLICT (chirp_id, shard) DO UPDATE SET count = like_count_shards.count - 1;
+
+-- name: GetLikeCounts :many
+SELECT chirp_id, SUM(count)::bigint AS like_count FROM like_count_shards
+WHERE chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[])
+GROUP BY chirp_id;
+
+-- name: GetLikedChirpIDs :many
+SELECT chirp_id FROM likes
+WHERE user_id = sqlc.arg(user_id) AND chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[]);
+
+-- name: GetLikers :many
+SELECT user_id, created_at FROM likes
+WHERE chirp_id = sqlc.arg(chirp_id)
+AND (created_at, user_id) < (sqlc.arg(cursor_created_at)::timestamp, sqlc.arg(cursor_id)::uuid)
+ORDER BY created_at DESC, user_id DESC
+LIMIT sqlc.arg(page_size);
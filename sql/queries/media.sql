@@ -0,0 +1,32 @@
+-- name: CreateMedia :one
+INSERT INTO media (id, created_at, user_id, content_type, width, height, size_bytes, storage_key, thumbnail_key)
+VALUES ($1, NOW(), $2, $3, $4, $5, $6, $7, $8)
+RETURNING *;
+
+-- name: GetMedia :one
+SELECT sqlc.embed(media), COALESCE(can_view_chirp(chirps.id, chirps.user_id, chirps.visibility, '00000000-0000-0000-0000-000000000000'), FALSE)::boolean AS public
+FROM media
+LEFT JOIN chirps ON chirps.id = media.chirp_id
+WHERE media.id = sqlc.arg(id)
+AND CASE
+	WHEN media.chirp_id IS NULL THEN media.user_id = sqlc.arg(viewer_id)::uuid
+	ELSE can_view_chirp(chirps.id, chirps.user_id, chirps.visibility, sqlc.arg(viewer_id)::uuid)
+END;
+
+-- name: CountAttachableMedia :one
+SELECT COUNT(*) FROM media
+WHERE id = ANY(sqlc.arg(media_ids)::uuid[])
+AND user_id = sqlc.arg(user_id)
+AND chirp_id IS NULL;
+
+-- name: AttachMedia :exec
+UPDATE media
+SET chirp_id = sqlc.arg(chirp_id), position = array_position(sqlc.arg(media_ids)::uuid[], id)
+WHERE id = ANY(sqlc.arg(media_ids)::uuid[])
+AND user_id = sqlc.arg(user_id)
+AND chirp_id IS NULL;
+
+-- name: GetMediaForChirps :many
+SELECT * FROM media
+WHERE chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[])
+ORDER BY chirp_id, position;
@@ -0,0 +1,49 @@
+-- name: GetModerationWords :many
+SELECT * FROM moderation_words
+ORDER BY word;
+
+-- name: UpsertModerationWord :one
+INSERT INTO moderation_words (word, action, created_at, updated_at)
+VALUES ($1, $2, NOW(), NOW())
+ON CONFLICT (word) DO UPDATE SET action = EXCLUDED.action, updated_at = NOW()
+RETURNING *;
+
+-- name: DeleteModerationWord :execrows
+DELETE FROM moderation_words
+WHERE word = $1;
+
+-- name: NotifyModerationWordsChanged :exec
+SELECT pg_notify('moderation_words', sqlc.arg(user_id)::text);
+
+-- name: CreateChirpFlag :exec
+INSERT INTO chirp_flags (chirp_id, words, created_at)
+VALUES ($1, $2, NOW());
+
+-- name: GetChirpFlags :many
+SELECT chirp_flags.words, chirp_flags.created_at AS flagged_at, sqlc.embed(chirps)
+FROM chirp_flags
+JOIN chirps ON chirps.id = chirp_flags.chirp_id
+WHERE (chirp_flags.created_at, chirp_flags.chirp_id) < (sqlc.arg(cursor_created_at)::timestamp, sqlc.arg(cursor_id)::uuid)
+ORDER BY chirp_flags.created_at DESC, chirp_flags.chirp_id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: CreateModerationLogEntry :one
+INSERT INTO moderation_log (id, created_at, moderator_id, action, report_id, user_id, chirp_id, suspended_until, note)
+VALUES (
+	gen_random_uuid(),
+	NOW(),
+	$1,
+	$2,
+	$3,
+	$4,
+	$5,
+	$6,
+	$7
+)
+RETURNING *;
+
+-- name: GetModerationLog :many
+SELECT * FROM moderation_log
+WHERE (created_at, id) < (sqlc.arg(cursor_created_at)::timestamp, sqlc.arg(cursor_id)::uuid)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(page_size);
@@ -0,0 +1,83 @@
+-- name: CreateNotification :exec
+WITH notification AS (
+	INSERT INTO notifications (id, created_at, updated_at, user_id, kind, actor_id, chirp_id, group_key, actor_count)
+	SELECT
+		gen_random_uuid(),
+		NOW(),
+		NOW(),
+		sqlc.arg(user_id)::uuid,
+		sqlc.arg(kind)::text,
+		sqlc.arg(actor_id)::uuid,
+		sqlc.narg(chirp_id)::uuid,
+		sqlc.narg(group_key)::text,
+		1
+	WHERE sqlc.arg(user_id)::uuid <> sqlc.arg(actor_id)::uuid
+	AND NOT blocked_between(sqlc.arg(user_id)::uuid, sqlc.arg(actor_id)::uuid)
+	AND NOT shadow_banned(sqlc.arg(actor_id)::uuid)
+	AND NOT EXISTS (
+		SELECT 1 FROM mutes
+		WHERE mutes.muter_id = sqlc.arg(user_id)::uuid AND mutes.muted_id = sqlc.arg(actor_id)::uuid
+	)
+	ON CONFLICT (user_id, group_key) WHERE read_at IS NULL AND group_key IS NOT NULL
+	DO UPDATE SET
+		actor_id = EXCLUDED.actor_id,
+		actor_count = notifications.actor_count + 1,
+		updated_at = NOW()
+	WHERE NOT EXISTS (
+		SELECT 1 FROM notification_actors
+		WHERE notification_actors.notification_id = notifications.id
+		AND notification_actors.actor_id = EXCLUDED.actor_id
+	)
+	RETURNING id, actor_id, group_key
+)
+INSERT INTO notification_actors (notification_id, actor_id)
+SELECT notification.id, notification.actor_id FROM notification
+WHERE notification.group_key IS NOT NULL
+ON CONFLICT DO NOTHING;
+
+-- name: CreateMentions :exec
+INSERT INTO chirp_mentions (chirp_id, user_id)
+SELECT sqlc.arg(chirp_id)::uuid, users.id FROM users
+WHERE users.id = ANY(sqlc.arg(user_ids)::uuid[])
+AND users.id <> sqlc.arg(author_id)::uuid
+AND NOT blocked_between(users.id, sqlc.arg(author_id)::uuid)
+ON CONFLICT DO NOTHING;
+
+-- name: NotifyMentions :exec
+INSERT INTO notifications (id, created_at, updated_at, user_id, kind, actor_id, chirp_id, actor_count)
+SELECT gen_random_uuid(), NOW(), NOW(), chirp_mentions.user_id, 'mention', chirps.user_id, chirps.id, 1
+FROM chirp_mentions
+JOIN chirps ON chirps.id = chirp_mentions.chirp_id
+WHERE chirp_mentions.chirp_id = $1
+AND can_view_chirp(chirps.id, chirps.user_id, chirps.visibility, chirp_mentions.user_id)
+AND NOT EXISTS (
+	SELECT 1 FROM mutes
+	WHERE mutes.muter_id = chirp_mentions.user_id AND mutes.muted_id = chirps.user_id
+);
+
+-- name: GetNotifications :many
+SELECT * FROM notifications
+WHERE notifications.user_id = sqlc.arg(user_id)
+AND (notifications.updated_at, notifications.id) < (sqlc.arg(cursor_updated_at)::timestamp, sqlc.arg(cursor_id)::uuid)
+AND NOT EXISTS (
+	SELECT 1 FROM mutes
+	WHERE mutes.muter_id = notifications.user_id AND mutes.muted_id = notifications.actor_id
+)
+ORDER BY notifications.updated_at DESC, notifications.id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: CountUnreadNotifications :one
+SELECT COUNT(*) FROM notifications
+WHERE notifications.user_id = $1 AND notifications.read_at IS NULL
+AND NOT EXISTS (
+	SELECT 1 FROM mutes
+	WHERE mutes.muter_id = notifications.user_id AND mutes.muted_id = notifications.actor_id
+);
+
+-- name: MarkNotificationRead :execrows
+UPDATE notifications SET read_at = COALESCE(read_at, NOW())
+WHERE id = $1 AND user_id = $2;
+
+-- name: MarkAllNotificationsRead :exec
+UPDATE notifications SET read_at = NOW()
+WHERE user_id = $1 AND read_at IS NULL;
@@ -0,0 +1,31 @@
+-- name: CreatePoll :exec
+INSERT INTO polls (chirp_id, created_at, closes_at)
+VALUES (sqlc.arg(chirp_id), NOW(), NOW() + make_interval(mins => sqlc.arg(duration_minutes)::integer));
+
+-- name: CreatePollOptions :exec
+INSERT INTO poll_options (chirp_id, position, label)
+SELECT sqlc.arg(chirp_id)::uuid, (options.ordinality - 1)::smallint, options.label
+FROM unnest(sqlc.arg(labels)::text[]) WITH ORDINALITY AS options(label, ordinality);
+
+-- name: GetPoll :one
+SELECT * FROM polls
+WHERE chirp_id = $1;
+
+-- name: CreatePollVote :execrows
+INSERT INTO poll_votes (chirp_id, user_id, position, created_at)
+VALUES ($1, $2, $3, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: GetPolls :many
+SELECT polls.chirp_id, polls.closes_at, poll_votes.position AS viewer_vote
+FROM polls
+LEFT JOIN poll_votes ON poll_votes.chirp_id = polls.chirp_id AND poll_votes.user_id = sqlc.arg(viewer_id)
+WHERE polls.chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[]);
+
+-- name: GetPollOptions :many
+SELECT poll_options.chirp_id, poll_options.position, poll_options.label, COUNT(poll_votes.user_id) AS votes
+FROM poll_options
+LEFT JOIN poll_votes ON poll_votes.chirp_id = poll_options.chirp_id AND poll_votes.position = poll_options.position
+WHERE poll_options.chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[])
+GROUP BY poll_options.chirp_id, poll_options.position
+ORDER BY poll_options.chirp_id, poll_options.position;
@@ -0,0 +1,14 @@
+-- name: LockRateLimitBucket :one
+INSERT INTO rate_limit_buckets (key, tokens, updated_at, full_at)
+VALUES ($1, $2, clock_timestamp(), clock_timestamp())
+ON CONFLICT (key) DO UPDATE SET key = excluded.key
+RETURNING *, clock_timestamp()::timestamptz AS now;
+
+-- name: UpdateRateLimitBucket :exec
+UPDATE rate_limit_buckets
+SET tokens = $2, updated_at = $3, full_at = $4
+WHERE key = $1;
+
+-- name: DeleteFullRateLimitBuckets :exec
+DELETE FROM rate_limit_buckets
+WHERE full_at <= NOW();
@@ -0,0 +1,29 @@
+-- name: CreateRechirp :one
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, rechirp_of_id)
+VALUES (
+	gen_random_uuid(),
+	NOW(),
+	NOW(),
+	'',
+	$1,
+	$2
+)
+ON CONFLICT (user_id, rechirp_of_id) WHERE rechirp_of_id IS NOT NULL DO NOTHING
+RETURNING *;
+
+-- name: GetRechirp :one
+SELECT * FROM chirps
+WHERE user_id = $1 AND rechirp_of_id = $2;
+
+-- name: DeleteRechirp :execrows
+DELETE FROM chirps
+WHERE user_id = $1 AND rechirp_of_id = $2;
+
+-- name: GetRechirpCounts :many
+SELECT COALESCE(rechirp_of_id, quote_of_id)::uuid AS chirp_id,
+	COUNT(rechirp_of_id) AS rechirp_count,
+	COUNT(quote_of_id) AS quote_count
+FROM chirps
+WHERE rechirp_of_id = ANY(sqlc.arg(chirp_ids)::uuid[])
+OR quote_of_id = ANY(sqlc.arg(chirp_ids)::uuid[])
+GROUP BY 1;
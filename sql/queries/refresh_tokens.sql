@@ -13,3 +13,24 @@ RETURNING *;
 -- name: GetUserFromRefreshToken :one
 SELECT * FROM refresh_tokens
 WHERE token = $1;
+
+-- name: RevokeRefreshToken :one
+UPDATE refresh_tokens SET revoked_at = NOW(), updated_at = NOW()
+WHERE token = $1
+RETURNING *;
+
+-- name: RevokeRefreshTokensByUsers :exec
+UPDATE refresh_tokens SET revoked_at = NOW(), updated_at = NOW()
+WHERE user_id = ANY(sqlc.arg(user_ids)::uuid[]) AND revoked_at IS NULL;
+
+-- name: NotifySessionRevoked :exec
+SELECT pg_notify('sessions_revoked', sqlc.arg(user_id)::text);
+
+-- name: RevokeSessions :exec
+INSERT INTO session_revocations (user_id, revoked_at)
+VALUES ($1, NOW())
+ON CONFLICT (user_id) DO UPDATE SET revoked_at = excluded.revoked_at;
+
+-- name: GetSessionsRevokedAt :one
+SELECT revoked_at FROM session_revocations
+WHERE user_id = $1;
@@ -0,0 +1,61 @@
+-- name: CreateReport :one
+INSERT INTO reports (id, created_at, updated_at, reporter_id, user_id, chirp_id, reason, details)
+VALUES (
+	gen_random_uuid(),
+	NOW(),
+	NOW(),
+	$1,
+	$2,
+	$3,
+	$4,
+	$5
+)
+ON CONFLICT DO NOTHING
+RETURNING *;
+
+-- name: GetReportByID :one
+SELECT * FROM reports
+WHERE id = $1;
+
+-- name: GetReports :many
+SELECT * FROM reports
+WHERE status = ANY(sqlc.arg(statuses)::text[])
+AND (created_at, id) > (sqlc.arg(cursor_created_at)::timestamp, sqlc.arg(cursor_id)::uuid)
+ORDER BY created_at, id
+LIMIT sqlc.arg(page_size);
+
+-- name: GetChirpsForStaff :many
+SELECT * FROM chirps
+WHERE id = ANY(sqlc.arg(chirp_ids)::uuid[]);
+
+-- name: ClaimReport :one
+UPDATE reports
+SET status = 'claimed', claimed_by = sqlc.arg(moderator_id), claimed_at = NOW(), updated_at = NOW()
+WHERE id = sqlc.arg(id) AND status = 'open'
+RETURNING *;
+
+-- name: CloseReport :one
+UPDATE reports
+SET status = sqlc.arg(status),
+	claimed_by = COALESCE(claimed_by, sqlc.arg(moderator_id)),
+	claimed_at = COALESCE(claimed_at, NOW()),
+	closed_at = NOW(),
+	updated_at = NOW()
+WHERE id = sqlc.arg(id)
+AND (status = 'open' OR (status = 'claimed' AND claimed_by = sqlc.arg(moderator_id)))
+RETURNING *;
+
+-- name: HideChirp :exec
+INSERT INTO hidden_chirps (chirp_id, created_at)
+VALUES ($1, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: SuspendUser :exec
+UPDATE users
+SET suspended_until = $2, updated_at = NOW()
+WHERE id = $1;
+
+-- name: BanUser :exec
+UPDATE users
+SET banned_at = COALESCE(banned_at, NOW()), updated_at = NOW()
+WHERE id = $1;
@@ -0,0 +1,14 @@
+-- name: CreateShadowBan :execrows
+INSERT INTO shadow_bans (user_id, created_at, moderator_id)
+VALUES ($1, NOW(), $2)
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteShadowBan :execrows
+DELETE FROM shadow_bans
+WHERE user_id = $1;
+
+-- name: GetShadowBans :many
+SELECT * FROM shadow_bans
+WHERE (created_at, user_id) < (sqlc.arg(cursor_created_at)::timestamp, sqlc.arg(cursor_id)::uuid)
+ORDER BY created_at DESC, user_id DESC
+LIMIT sqlc.arg(page_size);
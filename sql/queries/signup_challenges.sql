@@ -0,0 +1,12 @@
+-- name: SpendChallenge :execrows
+WITH pruned AS (
+	DELETE FROM spent_challenges
+	WHERE spent_challenges.expires_at < sqlc.arg(now)
+)
+INSERT INTO spent_challenges (challenge, expires_at)
+VALUES (sqlc.arg(challenge), sqlc.arg(expires_at))
+ON CONFLICT DO NOTHING;
+
+-- name: CountRecentUsers :one
+SELECT COUNT(*) FROM users
+WHERE created_at > $1;
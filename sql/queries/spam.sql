@@ -0,0 +1,39 @@
+-- name: CountDuplicateChirps :one
+SELECT COUNT(*) FROM chirps
+WHERE md5(body) = md5(sqlc.arg(body)) AND body = sqlc.arg(body)
+AND created_at > sqlc.arg(since);
+
+-- name: CountRecentChirpsByUser :one
+SELECT COUNT(*) FROM chirps
+WHERE user_id = $1 AND created_at > $2;
+
+-- name: HoldChirp :exec
+INSERT INTO held_chirps (chirp_id, created_at, score)
+VALUES ($1, NOW(), $2);
+
+-- name: GetHeldChirps :many
+SELECT held_chirps.score, held_chirps.created_at AS held_at, sqlc.embed(chirps)
+FROM held_chirps
+JOIN chirps ON chirps.id = held_chirps.chirp_id
+WHERE (held_chirps.created_at, held_chirps.chirp_id) > (sqlc.arg(cursor_created_at)::timestamp, sqlc.arg(cursor_id)::uuid)
+ORDER BY held_chirps.created_at, held_chirps.chirp_id
+LIMIT sqlc.arg(page_size);
+
+-- name: DeleteHeldChirp :execrows
+DELETE FROM held_chirps
+WHERE chirp_id = $1;
+
+-- name: CreateSpamLabel :exec
+INSERT INTO spam_labels (chirp_id, created_at, body, spam, moderator_id)
+SELECT id, NOW(), body, sqlc.arg(spam), sqlc.arg(moderator_id)
+FROM chirps
+WHERE id = sqlc.arg(chirp_id)
+ON CONFLICT (chirp_id) DO UPDATE
+SET spam = EXCLUDED.spam, moderator_id = EXCLUDED.moderator_id, created_at = EXCLUDED.created_at;
+
+-- name: GetSpamLabels :many
+SELECT body, spam FROM spam_labels
+ORDER BY created_at;
+
+-- name: NotifyNewChirp :exec
+SELECT pg_notify('new_chirps', $1::text);
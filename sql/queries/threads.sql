@@ -0,0 +1,59 @@
+-- name: GetChirpAncestorIDs :many
+WITH RECURSIVE ancestors(id, in_reply_to_id, depth) AS (
+	SELECT c.id, c.in_reply_to_id, 0::integer FROM chirps AS c
+	WHERE c.id = sqlc.arg(chirp_id)
+	UNION ALL
+	SELECT parent.id, parent.in_reply_to_id, ancestors.depth + 1
+	FROM chirps AS parent
+	JOIN ancestors ON ancestors.in_reply_to_id = parent.id
+)
+SELECT ancestors.id FROM ancestors
+WHERE ancestors.depth > 0;
+
+-- name: GetChirpAncestors :many
+WITH RECURSIVE ancestors(id, in_reply_to_id, depth) AS (
+	SELECT c.id, c.in_reply_to_id, 0::integer FROM chirps AS c
+	WHERE c.id = sqlc.arg(chirp_id)
+	UNION ALL
+	SELECT parent.id, parent.in_reply_to_id, ancestors.depth + 1
+	FROM chirps AS parent
+	JOIN ancestors ON ancestors.in_reply_to_id = parent.id
+)
+SELECT chirps.* FROM chirps
+JOIN ancestors ON ancestors.id = chirps.id
+WHERE ancestors.depth > 0
+AND can_view_chirp(chirps.id, chirps.user_id, chirps.visibility, sqlc.arg(viewer_id)::uuid)
+ORDER BY ancestors.depth DESC;
+
+-- name: GetReplyTree :many
+WITH RECURSIVE tree(id, created_at, depth) AS (
+	(SELECT c.id, c.created_at, 1::integer FROM chirps AS c
+	WHERE c.in_reply_to_id = sqlc.arg(chirp_id)
+	AND can_view_chirp(c.id, c.user_id, c.visibility, sqlc.arg(viewer_id)::uuid)
+	AND (c.created_at, c.id) > (sqlc.arg(cursor_created_at)::timestamp, sqlc.arg(cursor_id)::uuid)
+	ORDER BY c.created_at, c.id
+	LIMIT sqlc.arg(page_size))
+	UNION ALL
+	SELECT reply.id, reply.created_at, tree.depth + 1 FROM tree
+	CROSS JOIN LATERAL (
+		SELECT c.id, c.created_at FROM chirps AS c
+		WHERE c.in_reply_to_id = tree.id
+		AND can_view_chirp(c.id, c.user_id, c.visibility, sqlc.arg(viewer_id)::uuid)
+		ORDER BY c.created_at, c.id
+		LIMIT sqlc.arg(replies_per_parent)::integer
+	) AS reply
+	WHERE tree.depth < sqlc.arg(max_depth)::integer
+), shown AS (
+	SELECT tree.id, tree.depth FROM tree
+	ORDER BY tree.depth, tree.created_at, tree.id
+	LIMIT sqlc.arg(max_replies)::integer
+)
+SELECT sqlc.embed(chirps), shown.depth, EXISTS (
+	SELECT 1 FROM chirps AS child
+	WHERE child.in_reply_to_id = chirps.id
+	AND child.id NOT IN (SELECT shown.id FROM shown)
+	AND can_view_chirp(child.id, child.user_id, child.visibility, sqlc.arg(viewer_id)::uuid)
+) AS more_replies
+FROM chirps
+JOIN shown ON shown.id = chirps.id
+ORDER BY shown.depth, chirps.created_at, chirps.id;
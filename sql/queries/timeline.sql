@@ -0,0 +1,144 @@
+-- name: FanOutChirp :exec
+INSERT INTO timeline_entries (user_id, chirp_id, chirp_created_at)
+SELECT follows.follower_id, chirps.id, chirps.created_at
+FROM chirps
+JOIN follows ON follows.followee_id = chirps.user_id
+LEFT JOIN follow_counts ON follow_counts.user_id = chirps.user_id
+WHERE chirps.id = sqlc.arg(chirp_id)
+AND COALESCE(follow_counts.follower_count, 0) < sqlc.arg(fan_out_threshold)::integer
+ON CONFLICT DO NOTHING;
+
+-- name: BackfillTimeline :exec
+INSERT INTO timeline_entries (user_id, chirp_id, chirp_created_at)
+SELECT sqlc.arg(user_id)::uuid, chirps.id, chirps.created_at
+FROM chirps
+WHERE chirps.user_id = sqlc.arg(followee_id)
+ORDER BY chirps.created_at DESC
+LIMIT sqlc.arg(backfill_size)
+ON CONFLICT DO NOTHING;
+
+-- name: PruneTimeline :exec
+DELETE FROM timeline_entries
+USING chirps
+WHERE timeline_entries.chirp_id = chirps.id
+AND timeline_entries.user_id = sqlc.arg(user_id)
+AND chirps.user_id = sqlc.arg(followee_id);
+
+-- name: GetHomeTimeline :many
+SELECT chirps.* FROM chirps
+WHERE chirps.id IN (
+	(SELECT timeline_entries.chirp_id FROM timeline_entries
+	JOIN chirps AS entry ON entry.id = timeline_entries.chirp_id
+	WHERE timeline_entries.user_id = sqlc.arg(user_id)
+	AND (timeline_entries.chirp_created_at, timeline_entries.chirp_id) < (sqlc.arg(cursor_created_at)::timestamp, sqlc.arg(cursor_id)::uuid)
+	AND NOT EXISTS (
+		SELECT 1 FROM mutes
+		WHERE mutes.muter_id = sqlc.arg(user_id) AND mutes.muted_id = entry.user_id
+	)
+	AND can_view_chirp(entry.id, entry.user_id, entry.visibility, sqlc.arg(user_id))
+	ORDER BY timeline_entries.chirp_created_at DESC, timeline_entries.chirp_id DESC
+	LIMIT sqlc.arg(page_size))
+	UNION ALL
+	(SELECT recent.id FROM chirps AS recent
+	JOIN follows ON follows.followee_id = recent.user_id
+	JOIN follow_counts ON follow_counts.user_id = recent.user_id
+	WHERE follows.follower_id = sqlc.arg(user_id)
+	AND follow_counts.follower_count >= sqlc.arg(fan_out_threshold)::integer
+	AND (recent.created_at, recent.id) < (sqlc.arg(cursor_created_at)::timestamp, sqlc.arg(cursor_id)::uuid)
+	AND NOT EXISTS (
+		SELECT 1 FROM mutes
+		WHERE mutes.muter_id = sqlc.arg(user_id) AND mutes.muted_id = recent.user_id
+	)
+	AND can_view_chirp(recent.id, recent.user_id, recent.visibility, sqlc.arg(user_id))
+	ORDER BY recent.created_at DESC, recent.id DESC
+	LIMIT sqlc.arg(page_size))
+	UNION ALL
+	(SELECT own.id FROM chirps AS own
+	WHERE own.user_id = sqlc.arg(user_id)
+	AND (own.created_at, own.id) < (sqlc.arg(cursor_created_at)::timestamp, sqlc.arg(cursor_id)::uuid)
+	ORDER BY own.created_at DESC, own.id DESC
+	LIMIT sqlc.arg(page_size))
+)
+AND (chirps.created_at, chirps.id) < (sqlc.arg(cursor_created_at)::timestamp, sqlc.arg(cursor_id)::uuid)
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: GetChirpAudience :many
+SELECT viewers.id::uuid AS user_id, (
+	(chirps.user_id = viewers.id OR EXISTS (
+		SELECT 1 FROM follows
+		WHERE follows.follower_id = viewers.id AND follows.followee_id = chirps.user_id
+	))
+	AND NOT EXISTS (
+		SELECT 1 FROM mutes
+		WHERE mutes.muter_id = viewers.id AND mutes.muted_id = chirps.user_id
+	)
+)::boolean AS in_home
+FROM chirps
+CROSS JOIN unnest(sqlc.arg(user_ids)::uuid[]) AS viewers(id)
+WHERE chirps.id = sqlc.arg(chirp_id)
+AND can_view_chirp(chirps.id, chirps.user_id, chirps.visibility, viewers.id);
+
+-- name: PruneTimeline :exec
+DELETE FROM timeline_entries
+USING chirps
+WHERE timeline_entries.chirp_id = chirps.id
+AND timeline_entries.user_id = sqlc.arg(user_id)
+AND chirps.user_id = sqlc.arg(followee_id);
+
+-- name: GetHomeTimeline :many
+SELECT chirps.* FROM chirps
+WHERE chirps.id IN (
+	(SELECT timeline_entries.chirp_id FROM timeline_entries
+	JOIN chirps AS entry ON entry.id = timeline_entries.chirp_id
+	WHERE timeline_entries.user_id = sqlc.arg(user_id)
+	AND (timeline_entries.chirp_created_at, timeline_entries.chirp_id) < (sqlc.arg(cursor_created_at)::timestamp, sqlc.arg(cursor_id)::uuid)
+	AND NOT EXISTS (
+		SELECT 1 FROM mutes
+		WHERE mutes.muter_id = sqlc.arg(user_id) AND mutes.muted_id = entry.user_id
+	)
+	AND can_view_chirp(entry.id, entry.user_id, entry.visibility, sqlc.arg(user_id))
+	ORDER BY timeline_entries.chirp_created_at DESC, timeline_entries.chirp_id DESC
+	LIMIT sqlc.arg(page_size))
+	UNION ALL
+	(SELECT recent.id FROM chirps AS recent
+	JOIN follows ON follows.followee_id = recent.user_id
+	JOIN follow_counts ON follow_counts.user_id = recent.user_id
+	WHERE follows.follower_id = sqlc.arg(user_id)
+	AND follow_counts.follower_count >= sqlc.arg(fan_out_threshold)::integer
+	AND (recent.created_at, recent.id) < (sqlc.arg(cursor_created_at)::timestamp, sqlc.arg(cursor_id)::uuid)
+	AND NOT EXISTS (
+		SELECT 1 FROM mutes
+		WHERE mutes.muter_id = sqlc.arg(user_id) AND mutes.muted_id = recent.user_id
+	)
+	AND can_view_chirp(recent.id, recent.user_id, recent.visibility, sqlc.arg(user_id))
+	ORDER BY recent.created_at DESC, recent.id DESC
+	LIMIT sqlc.arg(page_size))
+	UNION ALL
+	(SELECT own.id FROM chirps AS own
+	WHERE own.user_id = sqlc.arg(user_id)
+	AND (own.created_at, own.id) < (sqlc.arg(cursor_created_at)::timestamp, sqlc.arg(cursor_id)::uuid)
+	ORDER BY own.created_at DESC, own.id DESC
+	LIMIT sqlc.arg(page_size))
+)
+AND (chirps.created_at, chirps.id) < (sqlc.arg(cursor_created_at)::timestamp, sqlc.arg(cursor_id)::uuid)
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: IsInHomeTimeline :one
+SELECT EXISTS (
+	SELECT 1 FROM chirps
+	WHERE chirps.id = sqlc.arg(chirp_id)
+	AND (
+		chirps.user_id = sqlc.arg(user_id)
+		OR EXISTS (
+			SELECT 1 FROM follows
+			WHERE follows.follower_id = sqlc.arg(user_id) AND follows.followee_id = chirps.user_id
+		)
+	)
+	AND can_view_chirp(chirps.id, chirps.user_id, chirps.visibility, sqlc.arg(user_id))
+	AND NOT EXISTS (
+		SELECT 1 FROM mutes
+		WHERE mutes.muter_id = sqlc.arg(user_id) AND mutes.muted_id = chirps.user_id
+	)
+);
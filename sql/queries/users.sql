@@ -1,14 +1,28 @@
 -- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, email, hashed_password)
+INSERT INTO users (id, created_at, updated_at, email, hashed_password, invited_by)
 VALUES (
 	gen_random_uuid(),
 	NOW(),
 	NOW(),
 	$1,
-	$2
+	$2,
+	$3
 )
 RETURNING *;
 
 -- name: GetUser :one
 SELECT * FROM users 
 WHERE email = $1;
+
+-- name: GetUserByID :one
+SELECT * FROM users
+WHERE id = $1;
+
+-- name: GetUserForViewer :one
+SELECT * FROM users
+WHERE id = sqlc.arg(id)
+AND NOT blocked_between(id, sqlc.arg(viewer_id)::uuid);
+
+-- name: CountUsersByIDs :one
+SELECT COUNT(*) FROM users
+WHERE id = ANY(sqlc.arg(ids)::uuid[]);
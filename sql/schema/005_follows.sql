@@ -0,0 +1,36 @@
+-- +goose Up
+CREATE TABLE follows(
+	follower_id UUID NOT NULL,
+	followee_id UUID NOT NULL,
+	created_at TIMESTAMP NOT NULL,
+	PRIMARY KEY (follower_id, followee_id),
+	CHECK (follower_id <> followee_id),
+	FOREIGN KEY (follower_id) REFERENCES users(id) ON DELETE CASCADE,
+	FOREIGN KEY (followee_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX follows_followee_idx ON follows (followee_id, created_at DESC);
+
+CREATE TABLE follow_counts(
+	user_id UUID PRIMARY KEY,
+	follower_count INTEGER NOT NULL DEFAULT 0,
+	following_count INTEGER NOT NULL DEFAULT 0,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE timeline_entries(
+	user_id UUID NOT NULL,
+	chirp_id UUID NOT NULL,
+	chirp_created_at TIMESTAMP NOT NULL,
+	PRIMARY KEY (user_id, chirp_id),
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+	FOREIGN KEY (chirp_id) REFERENCES chirps(id) ON DELETE CASCADE
+);
+CREATE INDEX timeline_entries_user_idx ON timeline_entries (user_id, chirp_created_at DESC, chirp_id DESC);
+
+CREATE INDEX chirps_user_created_idx ON chirps (user_id, created_at DESC, id DESC);
+
+-- +goose Down
+DROP INDEX chirps_user_created_idx;
+DROP TABLE timeline_entries;
+DROP TABLE follow_counts;
+DROP TABLE follows;
@@ -0,0 +1,25 @@
+-- +goose Up
+CREATE TABLE likes(
+	chirp_id UUID NOT NULL,
+	user_id UUID NOT NULL,
+	created_at TIMESTAMP NOT NULL,
+	PRIMARY KEY (chirp_id, user_id),
+	FOREIGN KEY (chirp_id) REFERENCES chirps(id) ON DELETE CASCADE,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX likes_chirp_created_idx ON likes (chirp_id, created_at DESC, user_id DESC);
+CREATE INDEX likes_user_idx ON likes (user_id, chirp_id);
+
+-- Like counts are spread over several rows per chirp so that concurrent
+-- likes on a popular chirp don't all contend for the same row lock.
+CREATE TABLE like_count_shards(
+	chirp_id UUID NOT NULL,
+	shard SMALLINT NOT NULL,
+	count INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY (chirp_id, shard),
+	FOREIGN KEY (chirp_id) REFERENCES chirps(id) ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE like_count_shards;
+DROP TABLE likes;
@@ -0,0 +1,19 @@
+-- +goose Up
+-- Rechirps and quotes point at their original without a foreign key so that
+-- deleting the original leaves them in place, rendered with a tombstone.
+ALTER TABLE chirps
+ADD COLUMN rechirp_of_id UUID,
+ADD COLUMN quote_of_id UUID,
+ADD CONSTRAINT chirps_single_original CHECK (rechirp_of_id IS NULL OR quote_of_id IS NULL);
+CREATE UNIQUE INDEX chirps_rechirp_unique_idx ON chirps (user_id, rechirp_of_id) WHERE rechirp_of_id IS NOT NULL;
+CREATE INDEX chirps_rechirp_of_idx ON chirps (rechirp_of_id) WHERE rechirp_of_id IS NOT NULL;
+CREATE INDEX chirps_quote_of_idx ON chirps (quote_of_id) WHERE quote_of_id IS NOT NULL;
+
+-- +goose Down
+DROP INDEX chirps_quote_of_idx;
+DROP INDEX chirps_rechirp_of_idx;
+DROP INDEX chirps_rechirp_unique_idx;
+ALTER TABLE chirps
+DROP CONSTRAINT chirps_single_original,
+DROP COLUMN quote_of_id,
+DROP COLUMN rechirp_of_id;
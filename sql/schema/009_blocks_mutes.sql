@@ -0,0 +1,37 @@
+-- +goose Up
+CREATE TABLE blocks(
+	blocker_id UUID NOT NULL,
+	blocked_id UUID NOT NULL,
+	created_at TIMESTAMP NOT NULL,
+	PRIMARY KEY (blocker_id, blocked_id),
+	CHECK (blocker_id <> blocked_id),
+	FOREIGN KEY (blocker_id) REFERENCES users(id) ON DELETE CASCADE,
+	FOREIGN KEY (blocked_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX blocks_blocked_idx ON blocks (blocked_id, blocker_id);
+
+CREATE TABLE mutes(
+	muter_id UUID NOT NULL,
+	muted_id UUID NOT NULL,
+	created_at TIMESTAMP NOT NULL,
+	PRIMARY KEY (muter_id, muted_id),
+	CHECK (muter_id <> muted_id),
+	FOREIGN KEY (muter_id) REFERENCES users(id) ON DELETE CASCADE,
+	FOREIGN KEY (muted_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- +goose StatementBegin
+CREATE FUNCTION blocked_between(a UUID, b UUID) RETURNS BOOLEAN
+LANGUAGE sql STABLE AS $$
+	SELECT EXISTS (
+		SELECT 1 FROM blocks
+		WHERE (blocks.blocker_id = a AND blocks.blocked_id = b)
+		OR (blocks.blocker_id = b AND blocks.blocked_id = a)
+	)
+$$;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP FUNCTION blocked_between;
+DROP TABLE mutes;
+DROP TABLE blocks;
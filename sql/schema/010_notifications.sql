@@ -0,0 +1,33 @@
+-- +goose Up
+CREATE TABLE chirp_mentions(
+	chirp_id UUID NOT NULL,
+	user_id UUID NOT NULL,
+	PRIMARY KEY (chirp_id, user_id),
+	FOREIGN KEY (chirp_id) REFERENCES chirps(id) ON DELETE CASCADE,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX chirp_mentions_user_idx ON chirp_mentions (user_id);
+
+CREATE TABLE notifications(
+	id UUID PRIMARY KEY,
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL,
+	user_id UUID NOT NULL,
+	kind TEXT NOT NULL CHECK (kind IN ('mention', 'reply', 'like', 'follow', 'rechirp')),
+	actor_id UUID NOT NULL,
+	chirp_id UUID,
+	group_key TEXT,
+	actor_count INTEGER NOT NULL DEFAULT 1,
+	read_at TIMESTAMP,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+	FOREIGN KEY (actor_id) REFERENCES users(id) ON DELETE CASCADE,
+	FOREIGN KEY (chirp_id) REFERENCES chirps(id) ON DELETE CASCADE
+);
+CREATE INDEX notifications_user_idx ON notifications (user_id, updated_at DESC, id DESC);
+-- Unread notifications with the same group key collapse into one row.
+CREATE UNIQUE INDEX notifications_unread_group_idx ON notifications (user_id, group_key)
+WHERE read_at IS NULL AND group_key IS NOT NULL;
+
+-- +goose Down
+DROP TABLE notifications;
+DROP TABLE chirp_mentions;
@@ -0,0 +1,21 @@
+-- +goose Up
+CREATE INDEX chirps_created_idx ON chirps (created_at, id);
+
+-- +goose StatementBegin
+CREATE FUNCTION notify_new_chirp() RETURNS trigger
+LANGUAGE plpgsql AS $$
+BEGIN
+	PERFORM pg_notify('new_chirps', NEW.id::text);
+	RETURN NEW;
+END;
+$$;
+-- +goose StatementEnd
+
+CREATE TRIGGER chirps_notify_insert
+AFTER INSERT ON chirps
+FOR EACH ROW EXECUTE FUNCTION notify_new_chirp();
+
+-- +goose Down
+DROP TRIGGER chirps_notify_insert ON chirps;
+DROP FUNCTION notify_new_chirp;
+DROP INDEX chirps_created_idx;
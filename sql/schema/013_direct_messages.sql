@@ -0,0 +1,37 @@
+-- +goose Up
+CREATE TABLE conversations(
+	id UUID PRIMARY KEY,
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL,
+	-- Set for one-to-one conversations so each pair of users has at most one.
+	-- Group conversations leave it NULL.
+	direct_key TEXT UNIQUE
+);
+
+CREATE TABLE conversation_members(
+	conversation_id UUID NOT NULL,
+	user_id UUID NOT NULL,
+	joined_at TIMESTAMP NOT NULL,
+	last_read_at TIMESTAMP,
+	PRIMARY KEY (conversation_id, user_id),
+	FOREIGN KEY (conversation_id) REFERENCES conversations(id) ON DELETE CASCADE,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX conversation_members_user_idx ON conversation_members (user_id);
+
+CREATE TABLE direct_messages(
+	id UUID PRIMARY KEY,
+	created_at TIMESTAMP NOT NULL,
+	conversation_id UUID NOT NULL,
+	sender_id UUID NOT NULL,
+	body TEXT NOT NULL,
+	FOREIGN KEY (conversation_id) REFERENCES conversations(id) ON DELETE CASCADE,
+	FOREIGN KEY (sender_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX direct_messages_conversation_idx ON direct_messages (conversation_id, created_at DESC, id DESC);
+CREATE INDEX direct_messages_sender_idx ON direct_messages (sender_id, created_at);
+
+-- +goose Down
+DROP TABLE direct_messages;
+DROP TABLE conversation_members;
+DROP TABLE conversations;
@@ -0,0 +1,21 @@
+-- +goose Up
+CREATE TABLE media(
+	id UUID PRIMARY KEY,
+	created_at TIMESTAMP NOT NULL,
+	user_id UUID NOT NULL,
+	-- NULL until the upload is attached to a chirp.
+	chirp_id UUID,
+	position SMALLINT NOT NULL DEFAULT 0,
+	content_type TEXT NOT NULL,
+	width INTEGER NOT NULL,
+	height INTEGER NOT NULL,
+	size_bytes BIGINT NOT NULL,
+	storage_key TEXT NOT NULL,
+	thumbnail_key TEXT NOT NULL,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+	FOREIGN KEY (chirp_id) REFERENCES chirps(id) ON DELETE CASCADE
+);
+CREATE INDEX media_chirp_idx ON media (chirp_id, position) WHERE chirp_id IS NOT NULL;
+
+-- +goose Down
+DROP TABLE media;
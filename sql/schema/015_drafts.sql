@@ -0,0 +1,23 @@
+-- +goose Up
+CREATE TABLE drafts(
+	id UUID PRIMARY KEY,
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL,
+	user_id UUID NOT NULL,
+	body TEXT NOT NULL,
+	in_reply_to_id UUID,
+	quote_of_id UUID,
+	mentions UUID[] NOT NULL DEFAULT '{}',
+	media_ids UUID[] NOT NULL DEFAULT '{}',
+	-- NULL for a plain draft. Scheduled drafts are published by the scheduler
+	-- once this passes and then deleted.
+	publish_at TIMESTAMP,
+	-- Why a scheduled publish was rejected. Editing the draft clears it.
+	failure TEXT,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX drafts_user_idx ON drafts (user_id, updated_at DESC, id DESC);
+CREATE INDEX drafts_due_idx ON drafts (publish_at) WHERE publish_at IS NOT NULL AND failure IS NULL;
+
+-- +goose Down
+DROP TABLE drafts;
@@ -0,0 +1,41 @@
+-- +goose Up
+CREATE TABLE polls(
+	chirp_id UUID PRIMARY KEY,
+	created_at TIMESTAMP NOT NULL,
+	closes_at TIMESTAMP NOT NULL,
+	FOREIGN KEY (chirp_id) REFERENCES chirps(id) ON DELETE CASCADE
+);
+
+CREATE TABLE poll_options(
+	chirp_id UUID NOT NULL,
+	position SMALLINT NOT NULL,
+	label TEXT NOT NULL,
+	PRIMARY KEY (chirp_id, position),
+	FOREIGN KEY (chirp_id) REFERENCES polls(chirp_id) ON DELETE CASCADE
+);
+
+-- The primary key allows one vote per user per poll.
+CREATE TABLE poll_votes(
+	chirp_id UUID NOT NULL,
+	user_id UUID NOT NULL,
+	position SMALLINT NOT NULL,
+	created_at TIMESTAMP NOT NULL,
+	PRIMARY KEY (chirp_id, user_id),
+	FOREIGN KEY (chirp_id, position) REFERENCES poll_options(chirp_id, position) ON DELETE CASCADE,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX poll_votes_option_idx ON poll_votes (chirp_id, position);
+
+-- Drafts keep the poll's duration rather than a closing time, so a scheduled
+-- poll runs for as long as intended from when it's published.
+ALTER TABLE drafts
+ADD COLUMN poll_options TEXT[] NOT NULL DEFAULT '{}',
+ADD COLUMN poll_duration_minutes INTEGER;
+
+-- +goose Down
+ALTER TABLE drafts
+DROP COLUMN poll_duration_minutes,
+DROP COLUMN poll_options;
+DROP TABLE poll_votes;
+DROP TABLE poll_options;
+DROP TABLE polls;
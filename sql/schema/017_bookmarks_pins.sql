@@ -0,0 +1,32 @@
+-- +goose Up
+CREATE TABLE bookmarks(
+	user_id UUID NOT NULL,
+	chirp_id UUID NOT NULL,
+	created_at TIMESTAMP NOT NULL,
+	PRIMARY KEY (user_id, chirp_id),
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+	FOREIGN KEY (chirp_id) REFERENCES chirps(id) ON DELETE CASCADE
+);
+CREATE INDEX bookmarks_user_created_idx ON bookmarks (user_id, created_at DESC, chirp_id DESC);
+
+-- Lets pinned_chirps require that users only pin their own chirps.
+ALTER TABLE chirps ADD CONSTRAINT chirps_id_user_key UNIQUE (id, user_id);
+
+-- Each pin takes one of a fixed number of slots, so the limit holds even
+-- when pins race.
+CREATE TABLE pinned_chirps(
+	user_id UUID NOT NULL,
+	chirp_id UUID NOT NULL,
+	slot SMALLINT NOT NULL CHECK (slot >= 0 AND slot < 3),
+	created_at TIMESTAMP NOT NULL,
+	PRIMARY KEY (user_id, chirp_id),
+	UNIQUE (user_id, slot),
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+	FOREIGN KEY (chirp_id, user_id) REFERENCES chirps(id, user_id) ON DELETE CASCADE
+);
+CREATE INDEX pinned_chirps_chirp_idx ON pinned_chirps (chirp_id);
+
+-- +goose Down
+DROP TABLE pinned_chirps;
+ALTER TABLE chirps DROP CONSTRAINT chirps_id_user_key;
+DROP TABLE bookmarks;
@@ -0,0 +1,33 @@
+-- +goose Up
+ALTER TABLE chirps ADD COLUMN visibility TEXT NOT NULL DEFAULT 'public'
+CHECK (visibility IN ('public', 'followers', 'mentioned'));
+
+ALTER TABLE drafts ADD COLUMN visibility TEXT NOT NULL DEFAULT 'public'
+CHECK (visibility IN ('public', 'followers', 'mentioned'));
+
+-- can_view_chirp is the one rule every read path applies. Authors always see
+-- their own chirps and mentioned users always see chirps that mention them;
+-- followers-only chirps are also shown to followers. Blocks hide chirps
+-- regardless of visibility.
+-- +goose StatementBegin
+CREATE FUNCTION can_view_chirp(chirp UUID, author UUID, vis TEXT, viewer UUID) RETURNS BOOLEAN
+LANGUAGE sql STABLE AS $$
+	SELECT NOT blocked_between(author, viewer) AND (
+		vis = 'public'
+		OR author = viewer
+		OR (vis = 'followers' AND EXISTS (
+			SELECT 1 FROM follows
+			WHERE follows.follower_id = viewer AND follows.followee_id = author
+		))
+		OR EXISTS (
+			SELECT 1 FROM chirp_mentions
+			WHERE chirp_mentions.chirp_id = chirp AND chirp_mentions.user_id = viewer
+		)
+	)
+$$;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP FUNCTION can_view_chirp;
+ALTER TABLE drafts DROP COLUMN visibility;
+ALTER TABLE chirps DROP COLUMN visibility;
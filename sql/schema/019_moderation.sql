@@ -0,0 +1,30 @@
+-- +goose Up
+-- Staff roles are granted directly in the database.
+ALTER TABLE users ADD COLUMN role TEXT NOT NULL DEFAULT 'user'
+CHECK (role IN ('user', 'moderator', 'admin'));
+
+CREATE TABLE moderation_words(
+	word TEXT PRIMARY KEY,
+	action TEXT NOT NULL CHECK (action IN ('mask', 'flag', 'reject')),
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL
+);
+INSERT INTO moderation_words (word, action, created_at, updated_at)
+VALUES
+	('kerfuffle', 'mask', NOW(), NOW()),
+	('sharbert', 'mask', NOW(), NOW()),
+	('fornax', 'mask', NOW(), NOW());
+
+-- Chirps let through with a word marked for review.
+CREATE TABLE chirp_flags(
+	chirp_id UUID PRIMARY KEY,
+	words TEXT[] NOT NULL,
+	created_at TIMESTAMP NOT NULL,
+	FOREIGN KEY (chirp_id) REFERENCES chirps(id) ON DELETE CASCADE
+);
+CREATE INDEX chirp_flags_created_idx ON chirp_flags (created_at DESC, chirp_id DESC);
+
+-- +goose Down
+DROP TABLE chirp_flags;
+DROP TABLE moderation_words;
+ALTER TABLE users DROP COLUMN role;
@@ -0,0 +1,110 @@
+-- +goose Up
+-- A banned user is never let back in; a suspension lapses on its own.
+ALTER TABLE users
+ADD COLUMN suspended_until TIMESTAMP,
+ADD COLUMN banned_at TIMESTAMP;
+
+CREATE TABLE reports(
+	id UUID PRIMARY KEY,
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL,
+	reporter_id UUID NOT NULL,
+	-- The reported user, who for a chirp report is its author.
+	user_id UUID NOT NULL,
+	chirp_id UUID,
+	reason TEXT NOT NULL CHECK (reason IN ('spam', 'harassment', 'hate', 'violence', 'sexual', 'self_harm', 'impersonation', 'other')),
+	details TEXT NOT NULL DEFAULT '',
+	status TEXT NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'claimed', 'resolved', 'dismissed')),
+	claimed_by UUID,
+	claimed_at TIMESTAMP,
+	closed_at TIMESTAMP,
+	FOREIGN KEY (reporter_id) REFERENCES users(id) ON DELETE CASCADE,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+	FOREIGN KEY (chirp_id) REFERENCES chirps(id) ON DELETE CASCADE,
+	FOREIGN KEY (claimed_by) REFERENCES users(id) ON DELETE SET NULL
+);
+-- One pending report per reporter and target; reporting again is a no-op.
+CREATE UNIQUE INDEX reports_pending_idx ON reports (reporter_id, user_id, COALESCE(chirp_id, '00000000-0000-0000-0000-000000000000'))
+WHERE status IN ('open', 'claimed');
+CREATE INDEX reports_queue_idx ON reports (status, created_at, id);
+
+CREATE TABLE hidden_chirps(
+	chirp_id UUID PRIMARY KEY,
+	created_at TIMESTAMP NOT NULL,
+	FOREIGN KEY (chirp_id) REFERENCES chirps(id) ON DELETE CASCADE
+);
+
+-- Every moderation decision. The log has no foreign keys so that it
+-- outlives the users and chirps it mentions, and a trigger refuses any
+-- change to a recorded entry.
+CREATE TABLE moderation_log(
+	id UUID PRIMARY KEY,
+	created_at TIMESTAMP NOT NULL,
+	moderator_id UUID NOT NULL,
+	action TEXT NOT NULL CHECK (action IN ('claim', 'dismiss', 'hide_chirp', 'suspend', 'ban')),
+	report_id UUID,
+	user_id UUID,
+	chirp_id UUID,
+	suspended_until TIMESTAMP,
+	note TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX moderation_log_created_idx ON moderation_log (created_at DESC, id DESC);
+
+-- +goose StatementBegin
+CREATE FUNCTION moderation_log_append_only() RETURNS trigger
+LANGUAGE plpgsql AS $$
+BEGIN
+	RAISE EXCEPTION 'moderation_log is append-only';
+END
+$$;
+-- +goose StatementEnd
+CREATE TRIGGER moderation_log_append_only BEFORE UPDATE OR DELETE ON moderation_log
+FOR EACH ROW EXECUTE FUNCTION moderation_log_append_only();
+
+-- Hidden chirps stay visible to their author only.
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION can_view_chirp(chirp UUID, author UUID, vis TEXT, viewer UUID) RETURNS BOOLEAN
+LANGUAGE sql STABLE AS $$
+	SELECT author = viewer OR (
+		NOT blocked_between(author, viewer)
+		AND NOT EXISTS (SELECT 1 FROM hidden_chirps WHERE hidden_chirps.chirp_id = chirp)
+		AND (
+			vis = 'public'
+			OR (vis = 'followers' AND EXISTS (
+				SELECT 1 FROM follows
+				WHERE follows.follower_id = viewer AND follows.followee_id = author
+			))
+			OR EXISTS (
+				SELECT 1 FROM chirp_mentions
+				WHERE chirp_mentions.chirp_id = chirp AND chirp_mentions.user_id = viewer
+			)
+		)
+	)
+$$;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION can_view_chirp(chirp UUID, author UUID, vis TEXT, viewer UUID) RETURNS BOOLEAN
+LANGUAGE sql STABLE AS $$
+	SELECT NOT blocked_between(author, viewer) AND (
+		vis = 'public'
+		OR author = viewer
+		OR (vis = 'followers' AND EXISTS (
+			SELECT 1 FROM follows
+			WHERE follows.follower_id = viewer AND follows.followee_id = author
+		))
+		OR EXISTS (
+			SELECT 1 FROM chirp_mentions
+			WHERE chirp_mentions.chirp_id = chirp AND chirp_mentions.user_id = viewer
+		)
+	)
+$$;
+-- +goose StatementEnd
+DROP TABLE moderation_log;
+DROP FUNCTION moderation_log_append_only;
+DROP TABLE hidden_chirps;
+DROP TABLE reports;
+ALTER TABLE users
+DROP COLUMN banned_at,
+DROP COLUMN suspended_until;
@@ -0,0 +1,71 @@
+-- +goose Up
+-- A shadow-banned user's chirps are hidden from everyone but themselves,
+-- without telling them.
+CREATE TABLE shadow_bans(
+	user_id UUID PRIMARY KEY,
+	created_at TIMESTAMP NOT NULL,
+	moderator_id UUID,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+	FOREIGN KEY (moderator_id) REFERENCES users(id) ON DELETE SET NULL
+);
+
+-- +goose StatementBegin
+CREATE FUNCTION shadow_banned(author UUID) RETURNS BOOLEAN
+LANGUAGE sql STABLE AS $$
+	SELECT EXISTS (SELECT 1 FROM shadow_bans WHERE shadow_bans.user_id = author)
+$$;
+-- +goose StatementEnd
+
+ALTER TABLE moderation_log
+DROP CONSTRAINT moderation_log_action_check,
+ADD CONSTRAINT moderation_log_action_check CHECK (action IN ('claim', 'dismiss', 'hide_chirp', 'suspend', 'ban', 'shadow_ban', 'lift_shadow_ban'));
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION can_view_chirp(chirp UUID, author UUID, vis TEXT, viewer UUID) RETURNS BOOLEAN
+LANGUAGE sql STABLE AS $$
+	SELECT author = viewer OR (
+		NOT shadow_banned(author)
+		AND NOT blocked_between(author, viewer)
+		AND NOT EXISTS (SELECT 1 FROM hidden_chirps WHERE hidden_chirps.chirp_id = chirp)
+		AND (
+			vis = 'public'
+			OR (vis = 'followers' AND EXISTS (
+				SELECT 1 FROM follows
+				WHERE follows.follower_id = viewer AND follows.followee_id = author
+			))
+			OR EXISTS (
+				SELECT 1 FROM chirp_mentions
+				WHERE chirp_mentions.chirp_id = chirp AND chirp_mentions.user_id = viewer
+			)
+		)
+	)
+$$;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION can_view_chirp(chirp UUID, author UUID, vis TEXT, viewer UUID) RETURNS BOOLEAN
+LANGUAGE sql STABLE AS $$
+	SELECT author = viewer OR (
+		NOT blocked_between(author, viewer)
+		AND NOT EXISTS (SELECT 1 FROM hidden_chirps WHERE hidden_chirps.chirp_id = chirp)
+		AND (
+			vis = 'public'
+			OR (vis = 'followers' AND EXISTS (
+				SELECT 1 FROM follows
+				WHERE follows.follower_id = viewer AND follows.followee_id = author
+			))
+			OR EXISTS (
+				SELECT 1 FROM chirp_mentions
+				WHERE chirp_mentions.chirp_id = chirp AND chirp_mentions.user_id = viewer
+			)
+		)
+	)
+$$;
+-- +goose StatementEnd
+ALTER TABLE moderation_log
+DROP CONSTRAINT moderation_log_action_check,
+ADD CONSTRAINT moderation_log_action_check CHECK (action IN ('claim', 'dismiss', 'hide_chirp', 'suspend', 'ban'))
+NOT VALID;
+DROP FUNCTION shadow_banned;
+DROP TABLE shadow_bans;
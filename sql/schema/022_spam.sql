@@ -0,0 +1,81 @@
+-- +goose Up
+-- Finds recent chirps with the same text without indexing whole bodies.
+CREATE INDEX chirps_body_hash_idx ON chirps (md5(body), created_at);
+
+-- Chirps the spam classifier scored too high to publish. They stay
+-- visible to their author only until a moderator releases or rejects them.
+CREATE TABLE held_chirps(
+	chirp_id UUID PRIMARY KEY,
+	created_at TIMESTAMP NOT NULL,
+	score DOUBLE PRECISION NOT NULL,
+	FOREIGN KEY (chirp_id) REFERENCES chirps(id) ON DELETE CASCADE
+);
+CREATE INDEX held_chirps_queue_idx ON held_chirps (created_at, chirp_id);
+
+-- Moderator decisions about whether a chirp was spam, for retraining the
+-- classifier. The text is copied so a label outlives its chirp.
+CREATE TABLE spam_labels(
+	chirp_id UUID PRIMARY KEY,
+	created_at TIMESTAMP NOT NULL,
+	body TEXT NOT NULL,
+	spam BOOLEAN NOT NULL,
+	moderator_id UUID,
+	FOREIGN KEY (moderator_id) REFERENCES users(id) ON DELETE SET NULL
+);
+
+ALTER TABLE moderation_log
+DROP CONSTRAINT moderation_log_action_check,
+ADD CONSTRAINT moderation_log_action_check CHECK (action IN ('claim', 'dismiss', 'hide_chirp', 'suspend', 'ban', 'shadow_ban', 'lift_shadow_ban', 'release_chirp', 'reject_chirp'));
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION can_view_chirp(chirp UUID, author UUID, vis TEXT, viewer UUID) RETURNS BOOLEAN
+LANGUAGE sql STABLE AS $$
+	SELECT author = viewer OR (
+		NOT shadow_banned(author)
+		AND NOT blocked_between(author, viewer)
+		AND NOT EXISTS (SELECT 1 FROM hidden_chirps WHERE hidden_chirps.chirp_id = chirp)
+		AND NOT EXISTS (SELECT 1 FROM held_chirps WHERE held_chirps.chirp_id = chirp)
+		AND (
+			vis = 'public'
+			OR (vis = 'followers' AND EXISTS (
+				SELECT 1 FROM follows
+				WHERE follows.follower_id = viewer AND follows.followee_id = author
+			))
+			OR EXISTS (
+				SELECT 1 FROM chirp_mentions
+				WHERE chirp_mentions.chirp_id = chirp AND chirp_mentions.user_id = viewer
+			)
+		)
+	)
+$$;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION can_view_chirp(chirp UUID, author UUID, vis TEXT, viewer UUID) RETURNS BOOLEAN
+LANGUAGE sql STABLE AS $$
+	SELECT author = viewer OR (
+		NOT shadow_banned(author)
+		AND NOT blocked_between(author, viewer)
+		AND NOT EXISTS (SELECT 1 FROM hidden_chirps WHERE hidden_chirps.chirp_id = chirp)
+		AND (
+			vis = 'public'
+			OR (vis = 'followers' AND EXISTS (
+				SELECT 1 FROM follows
+				WHERE follows.follower_id = viewer AND follows.followee_id = author
+			))
+			OR EXISTS (
+				SELECT 1 FROM chirp_mentions
+				WHERE chirp_mentions.chirp_id = chirp AND chirp_mentions.user_id = viewer
+			)
+		)
+	)
+$$;
+-- +goose StatementEnd
+ALTER TABLE moderation_log
+DROP CONSTRAINT moderation_log_action_check,
+ADD CONSTRAINT moderation_log_action_check CHECK (action IN ('claim', 'dismiss', 'hide_chirp', 'suspend', 'ban', 'shadow_ban', 'lift_shadow_ban'))
+NOT VALID;
+DROP TABLE spam_labels;
+DROP TABLE held_chirps;
+DROP INDEX chirps_body_hash_idx;
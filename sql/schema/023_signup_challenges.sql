@@ -0,0 +1,13 @@
+-- +goose Up
+-- Solved signup challenges, kept until they expire so none is used twice.
+CREATE TABLE spent_challenges(
+	challenge TEXT PRIMARY KEY,
+	expires_at TIMESTAMP NOT NULL
+);
+CREATE INDEX spent_challenges_expires_idx ON spent_challenges (expires_at);
+
+CREATE INDEX users_created_idx ON users (created_at);
+
+-- +goose Down
+DROP INDEX users_created_idx;
+DROP TABLE spent_challenges;
@@ -0,0 +1,27 @@
+-- +goose Up
+-- Invite codes are minted by admins, or by users from the invites they've
+-- earned. A code can be used up to max_uses times before it expires.
+CREATE TABLE invites(
+	id UUID PRIMARY KEY,
+	code TEXT NOT NULL UNIQUE,
+	created_at TIMESTAMP NOT NULL,
+	created_by UUID NOT NULL,
+	max_uses INTEGER NOT NULL CHECK (max_uses > 0),
+	uses INTEGER NOT NULL DEFAULT 0 CHECK (uses <= max_uses),
+	expires_at TIMESTAMP,
+	revoked_at TIMESTAMP,
+	FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX invites_created_by_idx ON invites (created_by);
+CREATE INDEX invites_created_idx ON invites (created_at DESC, id DESC);
+
+-- Who invited each account, so abuse can be traced back up the tree.
+ALTER TABLE users
+ADD COLUMN invited_by UUID REFERENCES users(id) ON DELETE SET NULL;
+CREATE INDEX users_invited_by_idx ON users (invited_by);
+
+-- +goose Down
+DROP INDEX users_invited_by_idx;
+ALTER TABLE users
+DROP COLUMN invited_by;
+DROP TABLE invites;
@@ -0,0 +1,13 @@
+-- +goose Up
+-- Token buckets shared by every instance. A bucket that has refilled is the
+-- same as no bucket, so rows past full_at can be deleted at any time.
+CREATE TABLE rate_limit_buckets(
+	key TEXT PRIMARY KEY,
+	tokens DOUBLE PRECISION NOT NULL,
+	updated_at TIMESTAMP NOT NULL,
+	full_at TIMESTAMP NOT NULL
+);
+CREATE INDEX rate_limit_buckets_full_idx ON rate_limit_buckets (full_at);
+
+-- +goose Down
+DROP TABLE rate_limit_buckets;
@@ -0,0 +1,10 @@
+-- +goose Up
+-- When each user last had their sessions revoked. Access tokens issued
+-- before then are refused by connections that outlive a single request.
+CREATE TABLE session_revocations(
+	user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+	revoked_at TIMESTAMPTZ NOT NULL
+);
+
+-- +goose Down
+DROP TABLE session_revocations;
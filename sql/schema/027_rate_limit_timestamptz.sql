@@ -0,0 +1,14 @@
+-- +goose Up
+-- Bucket times come from the database clock, in a zone-aware column.
+-- Existing buckets were written in each instance's local time, so they're
+-- dropped; that only forgives what callers spent in the current period.
+TRUNCATE rate_limit_buckets;
+ALTER TABLE rate_limit_buckets
+ALTER COLUMN updated_at TYPE TIMESTAMPTZ,
+ALTER COLUMN full_at TYPE TIMESTAMPTZ;
+
+-- +goose Down
+TRUNCATE rate_limit_buckets;
+ALTER TABLE rate_limit_buckets
+ALTER COLUMN updated_at TYPE TIMESTAMP,
+ALTER COLUMN full_at TYPE TIMESTAMP;
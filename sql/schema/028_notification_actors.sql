@@ -0,0 +1,18 @@
+-- +goose Up
+-- Who has acted on each grouped notification, so an actor who repeats
+-- themselves, by liking, unliking and liking again, is counted once.
+CREATE TABLE notification_actors(
+	notification_id UUID NOT NULL,
+	actor_id UUID NOT NULL,
+	PRIMARY KEY (notification_id, actor_id),
+	FOREIGN KEY (notification_id) REFERENCES notifications(id) ON DELETE CASCADE,
+	FOREIGN KEY (actor_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Open groups only know their latest actor.
+INSERT INTO notification_actors (notification_id, actor_id)
+SELECT id, actor_id FROM notifications
+WHERE read_at IS NULL AND group_key IS NOT NULL;
+
+-- +goose Down
+DROP TABLE notification_actors;
@@ -0,0 +1,12 @@
+-- +goose Up
+-- The number of pins is up to the server, through the slots it offers, so
+-- the table only checks that slots are valid.
+ALTER TABLE pinned_chirps
+DROP CONSTRAINT pinned_chirps_slot_check,
+ADD CONSTRAINT pinned_chirps_slot_check CHECK (slot >= 0);
+
+-- +goose Down
+DELETE FROM pinned_chirps WHERE slot >= 3;
+ALTER TABLE pinned_chirps
+DROP CONSTRAINT pinned_chirps_slot_check,
+ADD CONSTRAINT pinned_chirps_slot_check CHECK (slot >= 0 AND slot < 3);
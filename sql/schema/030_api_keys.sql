@@ -0,0 +1,15 @@
+-- +goose Up
+-- Keys issued by admins to integrations. Only a hash of each key is kept;
+-- the key itself is shown once, when it's created.
+CREATE TABLE api_keys(
+	id UUID PRIMARY KEY,
+	name TEXT NOT NULL,
+	key_hash TEXT NOT NULL UNIQUE,
+	created_at TIMESTAMP NOT NULL,
+	created_by UUID NOT NULL,
+	revoked_at TIMESTAMP,
+	FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE api_keys;
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"internal/database"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	streamHeartbeat = 15 * time.Second
+
+	// A reconnecting client is replayed at most streamReplayMax chirps from
+	// the last streamReplayMaxAge. Past either, it's sent a reset event and
+	// should reload what it missed through the API.
+	streamReplayPageSize = 200
+	streamReplayMax      = 1000
+	streamReplayMaxAge   = time.Hour
+)
+
+// streamFilter narrows a chirp stream to one author and/or one hashtag.
+// Replays apply it in GetChirpsAfter, and live chirps are checked with
+// matches.
+type streamFilter struct {
+	authorID uuid.UUID
+	hashtag  string
+}
+
+func (f streamFilter) matches(chirp database.Chirp) bool {
+	if f.authorID != uuid.Nil && chirp.UserID != f.authorID {
+		return false
+	}
+	if f.hashtag != "" && !hasHashtag(chirp.Body, f.hashtag) {
+		return false
+	}
+	return true
+}
+
+func hasHashtag(body, tag string) bool {
+	for _, word := range strings.Fields(body) {
+		word = strings.TrimRight(word, ".,!?;:\"')")
+		if strings.EqualFold(word, "#"+tag) {
+			return true
+		}
+	}
+	return false
+}
+
+func (cfg *apiConfig) handlerStreamChirps(w http.ResponseWriter, r *http.Request) {
+	viewerID := cfg.viewerID(r)
+
+	filter := streamFilter{hashtag: strings.TrimPrefix(r.URL.Query().Get("hashtag"), "#")}
+	if author := r.URL.Query().Get("author_id"); author != "" {
+		authorID, err := uuid.Parse(author)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid author ID", nil)
+			return
+		}
+		filter.authorID = authorID
+	}
+
+	var resumeCreatedAt time.Time
+	var resumeID uuid.UUID
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID != "" {
+		var err error
+		resumeCreatedAt, resumeID, err = decodeCursor(lastEventID)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid Last-Event-ID", nil)
+			return
+		}
+	}
+
+	// Subscribe before replaying so nothing created in between is missed.
+	events := cfg.realtime.subscribe()
+	defer cfg.realtime.unsubscribe(events)
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		slog.ErrorContext(r.Context(), "Streaming not supported", "err", err)
+		return
+	}
+
+	send := func(chirp Chirp) error {
+		dat, err := json.Marshal(chirp)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "id: %s\nevent: chirp\ndata: %s\n\n", encodeCursor(chirp.CreatedAt, chirp.ID), dat)
+		if err != nil {
+			return err
+		}
+		return rc.Flush()
+	}
+
+	// Chirps that were both replayed and announced live are sent once.
+	replayed := map[uuid.UUID]bool{}
+	if lastEventID != "" {
+		reset := time.Since(resumeCreatedAt) > streamReplayMaxAge
+		for !reset {
+			missed, err := cfg.db.GetChirpsAfter(r.Context(), database.GetChirpsAfterParams{
+				CursorCreatedAt: resumeCreatedAt,
+				CursorID:        resumeID,
+				AuthorID:        uuid.NullUUID{UUID: filter.authorID, Valid: filter.authorID != uuid.Nil},
+				Hashtag:         sql.NullString{String: filter.hashtag, Valid: filter.hashtag != ""},
+				ViewerID:        viewerID,
+				PageSize:        streamReplayPageSize,
+			})
+			if err != nil {
+				slog.ErrorContext(r.Context(), "Could not replay chirps from DB", "err", err)
+				return
+			}
+			chirps, err := cfg.loadChirps(r.Context(), viewerID, missed)
+			if err != nil {
+				slog.ErrorContext(r.Context(), "Could not load chirps from DB", "err", err)
+				return
+			}
+			for _, chirp := range chirps {
+				replayed[chirp.ID] = true
+				if err := send(chirp); err != nil {
+					return
+				}
+			}
+			if len(missed) < streamReplayPageSize {
+				break
+			}
+			reset = len(replayed) >= streamReplayMax
+			last := missed[len(missed)-1]
+			resumeCreatedAt, resumeID = last.CreatedAt, last.ID
+		}
+		// The empty id clears the client's Last-Event-ID, so it doesn't
+		// ask for the same replay when it next reconnects.
+		if reset {
+			if _, err := fmt.Fprint(w, "id\nevent: reset\ndata: {}\n\n"); err != nil {
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		case ev, ok := <-events:
+			if !ok {
+				return
+			}
+			if ev.channel != newChirpsChannel || replayed[ev.id] {
+				continue
+			}
+			dbChirp, err := cfg.db.GetChirpByID(r.Context(), database.GetChirpByIDParams{
+				ID:       ev.id,
+				ViewerID: viewerID,
+			})
+			if err != nil || !filter.matches(dbChirp) {
+				// Deleted already, not visible to this viewer or filtered
+				// out.
+				continue
+			}
+			chirp, err := cfg.loadChirp(r.Context(), viewerID, dbChirp)
+			if err != nil {
+				slog.ErrorContext(r.Context(), "Could not load chirp from DB", "err", err)
+				return
+			}
+			if err := send(chirp); err != nil {
+				return
+			}
+		}
+	}
+}
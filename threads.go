@@ -0,0 +1,122 @@
+package main
+
+import (
+	"github.com/google/uuid"
+	"internal/database"
+	"log/slog"
+	"net/http"
+)
+
+// Bounds on how much of the reply tree below the requested chirp is
+// expanded in a single response: how deep it goes, how many replies are
+// shown under each reply, and how many are shown in all, nearest first.
+// Replies with more below them than were shown are marked, and clients
+// expand them by fetching their own thread.
+const (
+	maxThreadDepth      = 10
+	maxRepliesPerParent = 10
+	maxThreadReplies    = 300
+)
+
+type ThreadReply struct {
+	Chirp
+	Depth       int32 `json:"depth"`
+	MoreReplies bool  `json:"more_replies"`
+}
+
+type Thread struct {
+	Root       Chirp         `json:"root"`
+	Ancestors  []Chirp       `json:"ancestors"`
+	Chirp      Chirp         `json:"chirp"`
+	Replies    []ThreadReply `json:"replies"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
+
+func (cfg *apiConfig) handlerGetThread(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", nil)
+		return
+	}
+	p, err := parseAscendingPage(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	viewerID := cfg.viewerID(r)
+	dbChirp, err := cfg.db.GetChirpByID(r.Context(), database.GetChirpByIDParams{
+		ID:       chirpID,
+		ViewerID: viewerID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Couldn't retrieve chirp", nil)
+		return
+	}
+
+	dbAncestors, err := cfg.db.GetChirpAncestors(r.Context(), database.GetChirpAncestorsParams{
+		ChirpID:  chirpID,
+		ViewerID: viewerID,
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not retrieve ancestors from DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve thread", nil)
+		return
+	}
+
+	rows, err := cfg.db.GetReplyTree(r.Context(), database.GetReplyTreeParams{
+		ChirpID:          chirpID,
+		ViewerID:         viewerID,
+		CursorCreatedAt:  p.cursorCreatedAt,
+		CursorID:         p.cursorID,
+		PageSize:         p.size,
+		RepliesPerParent: maxRepliesPerParent,
+		MaxDepth:         maxThreadDepth,
+		MaxReplies:       maxThreadReplies,
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not retrieve replies from DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve thread", nil)
+		return
+	}
+
+	// Load every chirp in the thread in one batch so counts cost one query.
+	all := append([]database.Chirp{dbChirp}, dbAncestors...)
+	for _, row := range rows {
+		all = append(all, row.Chirp)
+	}
+	chirps, err := cfg.loadChirps(r.Context(), viewerID, all)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not load chirp counts from DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve thread", nil)
+		return
+	}
+
+	thread := Thread{
+		Chirp:     chirps[0],
+		Ancestors: chirps[1 : 1+len(dbAncestors)],
+		Replies:   []ThreadReply{},
+	}
+	thread.Root = thread.Chirp
+	if len(thread.Ancestors) > 0 {
+		thread.Root = thread.Ancestors[0]
+	}
+
+	topLevel := 0
+	var last database.Chirp
+	for i, row := range rows {
+		thread.Replies = append(thread.Replies, ThreadReply{
+			Chirp:       chirps[1+len(dbAncestors)+i],
+			Depth:       row.Depth,
+			MoreReplies: row.MoreReplies,
+		})
+		if row.Depth == 1 {
+			topLevel++
+			last = row.Chirp
+		}
+	}
+	if topLevel > 0 {
+		thread.NextCursor = p.nextCursor(topLevel, last.CreatedAt, last.ID)
+	}
+	respondWithJSON(w, http.StatusOK, thread)
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"internal/database"
+	"log/slog"
+	"net/http"
+)
+
+// fanOutThreshold is the follower count at which an author's chirps stop
+// being copied into each follower's timeline on write and are instead merged
+// in at read time. It keeps chirp creation cheap for very large accounts.
+const fanOutThreshold = 10000
+
+type ChirpPage struct {
+	Chirps     []Chirp `json:"chirps"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+}
+
+func (cfg *apiConfig) handlerHomeTimeline(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized access", nil)
+		return
+	}
+	p, err := parsePage(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	dbChirps, err := cfg.db.GetHomeTimeline(r.Context(), database.GetHomeTimelineParams{
+		UserID:          userID,
+		CursorCreatedAt: p.cursorCreatedAt,
+		CursorID:        p.cursorID,
+		PageSize:        p.size,
+		FanOutThreshold: fanOutThreshold,
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not retrieve home timeline from DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve timeline", nil)
+		return
+	}
+	resp, err := cfg.newChirpPage(r.Context(), userID, p, dbChirps)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not load chirp counts from DB", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve timeline", nil)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+func (cfg *apiConfig) newChirpPage(ctx context.Context, viewerID uuid.UUID, p page, dbChirps []database.Chirp) (ChirpPage, error) {
+	chirps, err := cfg.loadChirps(ctx, viewerID, dbChirps)
+	if err != nil {
+		return ChirpPage{}, err
+	}
+	resp := ChirpPage{Chirps: chirps}
+	if len(dbChirps) > 0 {
+		last := dbChirps[len(dbChirps)-1]
+		resp.NextCursor = p.nextCursor(len(dbChirps), last.CreatedAt, last.ID)
+	}
+	return resp, nil
+}
+
+// fanOutChirp copies a new chirp into the home timelines of its author's
+// followers, unless the author is above fanOutThreshold. Every follower gets
+// an entry; whether they can see the chirp is checked when the timeline is
+// read, before each page is cut, so chirps reappear once a shadow ban or
+// block is lifted.
+func (cfg *apiConfig) fanOutChirp(ctx context.Context, chirp database.Chirp) {
+	err := cfg.db.FanOutChirp(ctx, database.FanOutChirpParams{
+		ChirpID:         chirp.ID,
+		FanOutThreshold: fanOutThreshold,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Could not fan out chirp", "chirp_id", chirp.ID, "err", err)
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"internal/auth"
+	"internal/database"
+	"internal/logging"
+	"internal/tracing"
+	"net/http"
+)
+
+// endSpan records err on span, if there was one, and ends it. Errors are
+// redacted as they would be in the logs.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		msg := logging.Redact(err.Error())
+		span.RecordError(errors.New(msg))
+		span.SetStatus(codes.Error, msg)
+	}
+	span.End()
+}
+
+// middlewareTracing starts a server span for each request, named after the
+// route mux sends it to and continuing the trace from the client's
+// traceparent header if it sent one.
+func (cfg *apiConfig) middlewareTracing(mux *http.ServeMux, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, route := mux.Handler(r)
+		if route == "" {
+			route = unmatchedRoute
+		}
+		ctx, span := tracing.StartServerSpan(r, route)
+		defer span.End()
+
+		rec := recordResponse(w)
+		next.ServeHTTP(rec, r.WithContext(ctx))
+
+		status := rec.statusCode()
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if rec.err != nil {
+			span.RecordError(errors.New(logging.Redact(rec.err.Error())))
+		}
+		if status > 499 {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	})
+}
+
+// tracedDB runs sqlc queries in spans named after the query.
+type tracedDB struct {
+	database.DBTX
+}
+
+func (db tracedDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := tracing.StartQuerySpan(ctx, query)
+	result, err := db.DBTX.ExecContext(ctx, query, args...)
+	endSpan(span, err)
+	return result, err
+}
+
+func (db tracedDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	ctx, span := tracing.StartQuerySpan(ctx, query)
+	stmt, err := db.DBTX.PrepareContext(ctx, query)
+	endSpan(span, err)
+	return stmt, err
+}
+
+// QueryContext's span covers the query up to its first rows, not the
+// caller reading through them.
+func (db tracedDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span := tracing.StartQuerySpan(ctx, query)
+	rows, err := db.DBTX.QueryContext(ctx, query, args...)
+	endSpan(span, err)
+	return rows, err
+}
+
+func (db tracedDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ctx, span := tracing.StartQuerySpan(ctx, query)
+	row := db.DBTX.QueryRowContext(ctx, query, args...)
+	endSpan(span, row.Err())
+	return row
+}
+
+// hashPassword hashes in a span, since bcrypt is slow on purpose.
+func hashPassword(ctx context.Context, password string) (string, error) {
+	_, span := tracing.Tracer.Start(ctx, "bcrypt.hash")
+	hash, err := auth.HashPassword(password)
+	endSpan(span, err)
+	return hash, err
+}
+
+// checkPasswordHash checks in a span. A wrong password isn't an error in
+// the trace.
+func checkPasswordHash(ctx context.Context, hash, password string) error {
+	_, span := tracing.Tracer.Start(ctx, "bcrypt.compare")
+	err := auth.CheckPasswordHash(hash, password)
+	span.End()
+	return err
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"context"
+	"internal/database"
+)
+
+// withTx runs fn inside a transaction, committing if it returns nil and
+// rolling back otherwise.
+func (cfg *apiConfig) withTx(ctx context.Context, fn func(q *database.Queries) error) error {
+	tx, err := cfg.dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := fn(database.New(tracedDB{tx})); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -2,18 +2,21 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"github.com/google/uuid"
-	"internal/auth"
 	"internal/database"
-	"log"
+	"log/slog"
 	"net/http"
 	"time"
 )
 
 func (cfg *apiConfig) handlerCreateUser(w http.ResponseWriter, r *http.Request) {
 	type parameters struct {
-		Password string `json:"password"`
-		Email    string `json:"email"`
+		Password     string `json:"password"`
+		Email        string `json:"email"`
+		PowChallenge string `json:"pow_challenge"`
+		PowSolution  string `json:"pow_solution"`
+		InviteCode   string `json:"invite_code"`
 	}
 	type User struct {
 		ID        uuid.UUID `json:"id"`
@@ -30,22 +33,62 @@ func (cfg *apiConfig) handlerCreateUser(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	hash, err := auth.HashPassword(params.Password)
+	// Checked before hashing the password, so unsolved signups cost the
+	// server nothing.
+	err = cfg.checkSignupProof(r.Context(), params.PowChallenge, params.PowSolution)
+	if errors.Is(err, errSignupProof) {
+		respondWithError(w, http.StatusBadRequest, "Invalid or missing proof of work", nil)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Could not check signup proof of work", "err", err)
+		respondWithError(w, http.StatusInternalServerError, "Could not process registration", nil)
+		return
+	}
+
+	inviteCode := normalizeInviteCode(params.InviteCode)
+	if cfg.inviteOnly && inviteCode == "" {
+		respondWithError(w, http.StatusForbidden, "An invite code is required to sign up", nil)
+		return
+	}
+
+	hash, err := hashPassword(r.Context(), params.Password)
 	if err != nil {
-		log.Printf("Something went wrong during registration: %v", err)
+		slog.ErrorContext(r.Context(), "Something went wrong during registration", "err", err)
 		respondWithError(w, http.StatusInternalServerError, "Could not process registration", nil)
 		return
 	}
 
-	user, err := cfg.db.CreateUser(r.Context(), database.CreateUserParams{
-		Email:          params.Email,
-		HashedPassword: hash,
+	// The invite is only used up if the account is actually created. A code
+	// is honored even when invites aren't required, so the tree is recorded.
+	var user database.User
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		invitedBy := uuid.NullUUID{}
+		if inviteCode != "" {
+			inviterID, err := redeemInvite(r, q, inviteCode)
+			if err != nil {
+				return err
+			}
+			invitedBy = uuid.NullUUID{UUID: inviterID, Valid: true}
+		}
+		var err error
+		user, err = q.CreateUser(r.Context(), database.CreateUserParams{
+			Email:          params.Email,
+			HashedPassword: hash,
+			InvitedBy:      invitedBy,
+		})
+		return err
 	})
+	if errors.Is(err, errInvalidInvite) {
+		respondWithError(w, http.StatusForbidden, "Invalid or expired invite code", nil)
+		return
+	}
 	if err != nil {
-		log.Printf("Could not create user %s in DB: %v", params.Email, err)
+		slog.ErrorContext(r.Context(), "Could not create user in DB", "err", err)
 		respondWithError(w, http.StatusInternalServerError, "Couldn't create user", nil)
 		return
 	}
+	cfg.metrics.usersCreated.Inc()
 	respondWithJSON(w, http.StatusCreated, User{
 		ID:        user.ID,
 		CreatedAt: user.CreatedAt,